@@ -27,7 +27,15 @@ import (
 	"github.com/apache/cloudstack-cloudmonkey/config"
 )
 
-func buildAPICacheMap(apiMap map[string][]*config.API) map[string][]*config.API {
+func buildAPICacheMap(verbMap map[string][]*config.API) map[string][]*config.API {
+	apiMap := make(map[string][]*config.API, len(verbMap))
+	for verb, apis := range verbMap {
+		for _, api := range apis {
+			if cfg.IsAPIAllowed(api.Name) {
+				apiMap[verb] = append(apiMap[verb], api)
+			}
+		}
+	}
 	for _, cmd := range cmd.AllCommands() {
 		verb := cmd.Name
 		if cmd.SubCommands != nil && len(cmd.SubCommands) > 0 {
@@ -103,6 +111,47 @@ func lastString(array []string) string {
 	return array[len(array)-1]
 }
 
+// openWhereInput returns the where= expression being typed at the end of the line,
+// including a quoted expression that spans several words
+func openWhereInput(line string) (string, bool) {
+	idx := strings.LastIndex(" "+line, " where=")
+	if idx < 0 {
+		return "", false
+	}
+	value := line[idx+len("where="):]
+	if !strings.Contains(value, " ") {
+		return strings.TrimLeft(value, "\"'"), true
+	}
+	for _, quote := range []string{"\"", "'"} {
+		if strings.HasPrefix(value, quote) && strings.Count(value, quote) == 1 {
+			return value[1:], true
+		}
+	}
+	return "", false
+}
+
+// completeWhereField completes the field name of the predicate being typed in a where= expression
+func completeWhereField(api *config.API, input string) (options [][]rune, offset int) {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ' ' || r == '('
+	})
+	field := ""
+	if len(fields) > 0 && !strings.HasSuffix(input, " ") && !strings.HasSuffix(input, "(") {
+		field = lastString(fields)
+	}
+	if strings.ContainsAny(field, "=!<>~") {
+		return nil, 0
+	}
+	for _, key := range api.ResponseKeys {
+		key = strings.TrimSuffix(key, ",")
+		if strings.HasPrefix(key, field) {
+			options = append(options, []rune(key[len(field):]))
+			offset = len(field)
+		}
+	}
+	return options, offset
+}
+
 type argOption struct {
 	Value  string
 	Detail string
@@ -335,6 +384,11 @@ func (t *autoCompleter) Do(line []rune, pos int) (options [][]rune, offset int)
 		return
 	}
 
+	// Auto-complete field names of a where= expression
+	if whereInput, ok := openWhereInput(string(line)); ok {
+		return completeWhereField(apiFound, whereInput)
+	}
+
 	// Auto-complete API arg
 	splitLine := strings.Split(string(line), " ")
 	line = trimSpaceLeft([]rune(splitLine[len(splitLine)-1]))
@@ -372,6 +426,9 @@ func (t *autoCompleter) Do(line []rune, pos int) (options [][]rune, offset int)
 				}
 				return
 			}
+			if arg.Type == config.FAKE {
+				return nil, 0
+			}
 
 			autocompleteAPI := findAutocompleteAPI(arg, apiFound, apiMap)
 			if autocompleteAPI == nil {
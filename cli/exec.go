@@ -18,11 +18,9 @@
 package cli
 
 import (
-	"fmt"
-	"os"
-	"os/exec"
-	"runtime"
+	"errors"
 	"strings"
+	"unicode"
 
 	"github.com/apache/cloudstack-cloudmonkey/cmd"
 	"github.com/apache/cloudstack-cloudmonkey/config"
@@ -32,25 +30,105 @@ import (
 // ExecLine executes a line of command
 func ExecLine(line string) error {
 	config.Debug("ExecLine line:", line)
-	args, err := shlex.Split(line)
+	words, err := splitWords(line)
 	if err != nil {
 		return err
 	}
 
-	if runtime.GOOS != "windows" {
-		for _, arg := range args {
-			if arg == "|" {
-				fullCommand := fmt.Sprintf("%s %v", strings.Join(os.Args, " "), line)
-				result, err := exec.Command("bash", "-c", fullCommand).Output()
-				fmt.Print(string(result))
-				return err
-			}
+	words, outputFile, appendToFile, err := splitRedirect(words)
+	if err != nil {
+		return err
+	}
+	if len(outputFile) > 0 {
+		restoreOutput, err := cmd.RedirectOutput(outputFile, appendToFile)
+		if err != nil {
+			return err
 		}
+		defer restoreOutput()
 	}
 
+	defer cmd.PageOutput(cfg)()
+
+	if hasPipe(words) {
+		stages, err := splitPipeline(words)
+		if err != nil {
+			return err
+		}
+		return execPipeline(stages)
+	}
+	args, err := shlex.Split(strings.Join(words, " "))
+	if err != nil {
+		return err
+	}
 	return ExecCmd(args)
 }
 
+// splitWords splits a line at the whitespace outside quotes, the words keep their
+// quotes and escapes so that only unquoted operators such as > and | are recognised
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inWord, escaped := false, false
+	for _, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case unicode.IsSpace(c):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		}
+		word.WriteRune(c)
+		inWord = true
+	}
+	if quote != 0 || escaped {
+		// report the unterminated quote or escape the way the args are parsed
+		_, err := shlex.Split(line)
+		return nil, err
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// splitRedirect separates a trailing > or >> file redirection from the words of
+// a line, a quoted or escaped > is an argument
+func splitRedirect(words []string) ([]string, string, bool, error) {
+	for idx, word := range words {
+		if !strings.HasPrefix(word, ">") {
+			continue
+		}
+		appendToFile := strings.HasPrefix(word, ">>")
+		outputFile := strings.TrimPrefix(strings.TrimPrefix(word, ">"), ">")
+		rest := words[idx+1:]
+		if len(outputFile) == 0 && len(rest) > 0 {
+			outputFile, rest = rest[0], rest[1:]
+		}
+		if len(outputFile) == 0 || len(rest) > 0 {
+			return nil, "", false, errors.New("invalid redirection, expected > or >> followed by a file name")
+		}
+		path, err := shlex.Split(outputFile)
+		if err != nil || len(path) != 1 {
+			return nil, "", false, errors.New("invalid redirection, expected > or >> followed by a file name")
+		}
+		return words[:idx], path[0], appendToFile, nil
+	}
+	return words, "", false, nil
+}
+
 // ExecCmd executes a single provided command
 func ExecCmd(args []string) error {
 	config.Debug("ExecCmd args: ", strings.Join(args, ", "))
@@ -0,0 +1,126 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"list zones", []string{"list", "zones"}},
+		{"  list   zones  ", []string{"list", "zones"}},
+		{`list zones name="zone 1"`, []string{"list", "zones", `name="zone 1"`}},
+		{`list zones keyword='a "b" c'`, []string{"list", "zones", `keyword='a "b" c'`}},
+		{`list zones keyword=a\ b`, []string{"list", "zones", `keyword=a\ b`}},
+		{`echo ">" '|' "it's"`, []string{"echo", `">"`, `'|'`, `"it's"`}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		words, err := splitWords(test.line)
+		if err != nil {
+			t.Errorf("splitWords(%q) failed: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(words, test.expected) {
+			t.Errorf("splitWords(%q) = %q, expected %q", test.line, words, test.expected)
+		}
+	}
+	for _, line := range []string{`list zones name="zone`, `list zones name='zone`, `list zones \`} {
+		if _, err := splitWords(line); err == nil {
+			t.Errorf("splitWords(%q) did not fail", line)
+		}
+	}
+}
+
+func TestSplitRedirect(t *testing.T) {
+	tests := []struct {
+		line         string
+		words        []string
+		outputFile   string
+		appendToFile bool
+	}{
+		{"list zones", []string{"list", "zones"}, "", false},
+		{"list zones > zones.json", []string{"list", "zones"}, "zones.json", false},
+		{"list zones >zones.json", []string{"list", "zones"}, "zones.json", false},
+		{"list zones >> zones.json", []string{"list", "zones"}, "zones.json", true},
+		{`list zones > "my zones.json"`, []string{"list", "zones"}, "my zones.json", false},
+		{`list zones | grep ">"`, []string{"list", "zones", "|", "grep", `">"`}, "", false},
+		{`list zones keyword='>1'`, []string{"list", "zones", `keyword='>1'`}, "", false},
+		{`list zones '>zones.json'`, []string{"list", "zones", `'>zones.json'`}, "", false},
+		{`list zones \> zones.json`, []string{"list", "zones", `\>`, "zones.json"}, "", false},
+	}
+	for _, test := range tests {
+		words, err := splitWords(test.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		words, outputFile, appendToFile, err := splitRedirect(words)
+		if err != nil {
+			t.Errorf("splitRedirect(%q) failed: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(words, test.words) || outputFile != test.outputFile || appendToFile != test.appendToFile {
+			t.Errorf("splitRedirect(%q) = %q, %q, %v, expected %q, %q, %v", test.line, words, outputFile, appendToFile,
+				test.words, test.outputFile, test.appendToFile)
+		}
+	}
+	for _, line := range []string{"list zones >", "list zones > a b", "list zones > a | grep b"} {
+		words, _ := splitWords(line)
+		if _, _, _, err := splitRedirect(words); err == nil || !strings.Contains(err.Error(), "invalid redirection") {
+			t.Errorf("splitRedirect(%q) returned error %v, expected an invalid redirection", line, err)
+		}
+	}
+}
+
+func TestSplitPipeline(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected [][]string
+	}{
+		{"list zones | grep zone1", [][]string{{"list", "zones"}, {"grep", "zone1"}}},
+		{`list zones | grep "|"`, [][]string{{"list", "zones"}, {"grep", "|"}}},
+		{`list zones | grep 'a | b' | head -n 2`, [][]string{{"list", "zones"}, {"grep", "a | b"}, {"head", "-n", "2"}}},
+		{`list zones keyword=\| | count`, [][]string{{"list", "zones", "keyword=|"}, {"count"}}},
+	}
+	for _, test := range tests {
+		words, err := splitWords(test.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stages, err := splitPipeline(words)
+		if err != nil {
+			t.Errorf("splitPipeline(%q) failed: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(stages, test.expected) {
+			t.Errorf("splitPipeline(%q) = %q, expected %q", test.line, stages, test.expected)
+		}
+	}
+	for _, line := range []string{"| grep a", "list zones |", "list zones | | grep a"} {
+		words, _ := splitWords(line)
+		if _, err := splitPipeline(words); err == nil || !strings.Contains(err.Error(), "invalid pipe") {
+			t.Errorf("splitPipeline(%q) returned error %v, expected an invalid pipe", line, err)
+		}
+	}
+}
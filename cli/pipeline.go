@@ -0,0 +1,123 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/apache/cloudstack-cloudmonkey/cmd"
+	"github.com/apache/cloudstack-cloudmonkey/config"
+	"github.com/google/shlex"
+)
+
+// splitPipeline splits the words of a line into the args of the commands separated
+// by an unquoted |
+func splitPipeline(words []string) ([][]string, error) {
+	var stages [][]string
+	start := 0
+	for idx := 0; idx <= len(words); idx++ {
+		if idx < len(words) && words[idx] != "|" {
+			continue
+		}
+		args, err := shlex.Split(strings.Join(words[start:idx], " "))
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return nil, errors.New("invalid pipe, expected a command before and after |")
+		}
+		stages = append(stages, args)
+		start = idx + 1
+	}
+	return stages, nil
+}
+
+// runPipeStage runs a command after a | with the output of the previous command
+// as its input, system commands are preferred over the built-in filters
+func runPipeStage(args []string, in io.Reader, out io.Writer) error {
+	if _, err := exec.LookPath(args[0]); err != nil && cmd.IsPipeFilter(args[0]) {
+		return cmd.RunPipeFilter(args, in, out)
+	}
+	command := exec.Command(args[0], args[1:]...)
+	command.Stdin = in
+	command.Stdout = out
+	command.Stderr = os.Stderr
+	err := command.Run()
+	if _, ok := err.(*exec.ExitError); ok {
+		return nil
+	}
+	return err
+}
+
+// execPipeline runs the first command of a pipeline in the current session and
+// streams its formatted output through the following commands
+func execPipeline(stages [][]string) error {
+	config.Debug("Executing pipeline: ", stages)
+	var in *io.PipeReader
+	var out *io.PipeWriter
+	in, out = io.Pipe()
+	previous := cmd.SetOutputWriter(out)
+
+	errs := make([]error, len(stages))
+	done := make(chan int, len(stages))
+	for idx, stage := range stages[1:] {
+		var stageOut io.Writer = previous
+		var nextIn *io.PipeReader
+		var nextOut *io.PipeWriter
+		if idx < len(stages)-2 {
+			nextIn, nextOut = io.Pipe()
+			stageOut = nextOut
+		}
+		go func(idx int, stage []string, stageIn *io.PipeReader, stageOut io.Writer, closer *io.PipeWriter) {
+			errs[idx] = runPipeStage(stage, stageIn, stageOut)
+			stageIn.Close()
+			if closer != nil {
+				closer.Close()
+			}
+			done <- idx
+		}(idx+1, stage, in, stageOut, nextOut)
+		in = nextIn
+	}
+
+	errs[0] = ExecCmd(stages[0])
+	cmd.SetOutputWriter(previous)
+	out.Close()
+	for range stages[1:] {
+		<-done
+	}
+
+	for _, err := range errs {
+		if err != nil && err != io.ErrClosedPipe {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasPipe(args []string) bool {
+	for _, arg := range args {
+		if arg == "|" {
+			return true
+		}
+	}
+	return false
+}
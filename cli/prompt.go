@@ -22,6 +22,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/apache/cloudstack-cloudmonkey/cmd"
 	"github.com/apache/cloudstack-cloudmonkey/config"
 	"github.com/chzyer/readline"
 )
@@ -64,6 +65,13 @@ func ExecPrompt() {
 	}
 	defer shell.Close()
 
+	cmd.ReadInput = func(prompt string) (string, error) {
+		shell.HistoryDisable()
+		defer shell.HistoryEnable()
+		shell.SetPrompt(prompt)
+		return shell.Readline()
+	}
+
 	cfg.HasShell = true
 	cfg.PrintHeader()
 
@@ -82,7 +90,7 @@ func ExecPrompt() {
 		}
 
 		if err = ExecLine(line); err != nil {
-			fmt.Println("🙈 Error:", err)
+			fmt.Println(cmd.FormatError(cfg, err))
 		}
 	}
 
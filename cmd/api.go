@@ -21,6 +21,8 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
 )
 
 var apiCommand *Command
@@ -30,6 +32,67 @@ func GetAPIHandler() *Command {
 	return apiCommand
 }
 
+// mergeDefaultArgs adds default args that are not provided on the command line
+func mergeDefaultArgs(defaultArgs []string, args []string) []string {
+	if len(defaultArgs) == 0 {
+		return args
+	}
+	provided := make(map[string]bool)
+	for _, arg := range args {
+		provided[strings.SplitN(arg, "=", 2)[0]] = true
+	}
+	var mergedArgs []string
+	for _, arg := range defaultArgs {
+		if !provided[strings.SplitN(arg, "=", 2)[0]] {
+			config.Debug("Adding default arg: ", arg)
+			mergedArgs = append(mergedArgs, arg)
+		}
+	}
+	return append(mergedArgs, args...)
+}
+
+// splitFakeArgs separates cloudmonkey specific args such as filter= from the
+// args that are sent to the API, unless the API has a real arg of the same name
+func splitFakeArgs(api *config.API, args []string) ([]string, map[string]string) {
+	fakeArgs := make(map[string]string)
+	var apiArgs []string
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			apiArgs = append(apiArgs, arg)
+			continue
+		}
+		isFake, isReal := false, false
+		for _, apiArg := range api.Args {
+			if apiArg.Name == parts[0]+"=" {
+				if apiArg.Type == config.FAKE {
+					isFake = true
+				} else {
+					isReal = true
+				}
+			}
+		}
+		if isFake {
+			fakeArgs[parts[0]] = parts[1]
+		}
+		if !isFake || isReal {
+			apiArgs = append(apiArgs, arg)
+		}
+	}
+	return apiArgs, fakeArgs
+}
+
+// splitFakeArgList splits the comma separated value of a fake arg such as filter=
+func splitFakeArgList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if len(strings.TrimSpace(item)) > 0 {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+	return items
+}
+
 func init() {
 	apiCommand = &Command{
 		Name: "api",
@@ -53,10 +116,26 @@ func init() {
 				}
 			}
 
+			assumeYes := r.Config.AssumeYes
+			var args []string
+			for _, arg := range apiArgs {
+				if arg == "--yes" || arg == "-y" {
+					assumeYes = true
+					continue
+				}
+				args = append(args, arg)
+			}
+			apiArgs = args
+
 			api := r.Config.GetCache()[apiName]
 			if api == nil {
 				return errors.New("unknown command or API requested")
 			}
+			if !r.Config.IsAPIAllowed(api.Name) {
+				return errors.New("API " + api.Name + " is not allowed in the read-only profile " + r.Config.Core.ProfileName)
+			}
+
+			apiArgs = mergeDefaultArgs(r.Config.GetDefaultArgs(api), apiArgs)
 
 			var missingArgs []string
 			for _, required := range api.RequiredArgs {
@@ -77,29 +156,111 @@ func init() {
 				return nil
 			}
 
+			if r.Config.Core.ValidateArgs != config.VALIDATE_OFF {
+				if problems := validateArgs(api, apiArgs); len(problems) > 0 {
+					if r.Config.Core.ValidateArgs == config.VALIDATE_WARN || isCacheOutdated(r) {
+						for _, problem := range problems {
+							fmt.Println("⚠️  Warning:", problem)
+						}
+						if r.Config.Core.ValidateArgs != config.VALIDATE_WARN {
+							fmt.Println("⚠️  The API cache may be older than the server, run 'sync' to update it")
+						}
+					} else {
+						for _, problem := range problems {
+							fmt.Println("💩 Invalid parameter:", problem)
+						}
+						return errors.New("invalid parameters provided, use 'set validateargs warn' if the API cache is older than the server")
+					}
+				}
+			}
+
+			if r.Config.RequiresConfirmation(api) && !assumeYes {
+				if err := confirmAPI(r, api, apiArgs); err != nil {
+					return err
+				}
+			}
+
+			apiArgs, fakeArgs := splitFakeArgs(api, apiArgs)
+			outputType := r.Config.Core.Output
+			options := outputOptions{
+				Filter:     splitFakeArgList(fakeArgs["filter"]),
+				SortBy:     splitFakeArgList(fakeArgs["sortby"]),
+				Explode:    splitFakeArgList(fakeArgs["explode"]),
+				Theme:      colorThemeFor(r.Config),
+				NDJSONKey:  r.Config.Core.NDJSONKey,
+				FieldOrder: splitFakeArgList(r.Config.Core.FieldOrder),
+			}
+			if len(fakeArgs["where"]) > 0 {
+				where, err := compileWhere(fakeArgs["where"])
+				if err != nil {
+					return err
+				}
+				options.Where = where
+			}
+			if len(fakeArgs["groupby"]) > 0 || len(fakeArgs["agg"]) > 0 {
+				aggregates, err := parseAggregates(fakeArgs["agg"])
+				if err != nil {
+					return err
+				}
+				options.GroupBy = splitFakeArgList(fakeArgs["groupby"])
+				options.Aggregates = aggregates
+			}
+			wide := r.Config.Core.Wide
+			if value, ok := fakeArgs["wide"]; ok {
+				wide = value != "false"
+			}
+			if !wide {
+				options.Columns = r.Config.GetColumns
+			}
+			truncate := r.Config.Core.Truncate
+			if value, ok := fakeArgs["truncate"]; ok {
+				truncate = value != "false"
+			}
+			if truncate {
+				options.Width = terminalWidth()
+				options.Wrap = splitFakeArgList(fakeArgs["wrap"])
+			}
+			humanize := r.Config.Core.Humanize
+			if value, ok := fakeArgs["humanize"]; ok {
+				humanize = value != "false"
+			}
+			if humanize {
+				options.Humanize = true
+				options.FieldTypes = api.ResponseTypes
+				options.TimeFormat = r.Config.Core.TimeFormat
+			}
+			if len(fakeArgs["template"]) > 0 || outputType == config.TEMPLATE {
+				outputTemplate, err := parseOutputTemplate(fakeArgs["template"])
+				if err != nil {
+					return err
+				}
+				options.Template = outputTemplate
+				outputType = config.TEMPLATE
+			}
+
+			if outputType == config.NDJSON && isStreamable(api, apiArgs) && len(options.SortBy) == 0 && len(options.Aggregates) == 0 && len(fakeArgs["query"]) == 0 {
+				return streamPages(r, api, apiArgs, outputType, options)
+			}
+
 			response, err := NewAPIRequest(r, api.Name, apiArgs, api.Async)
 			if err != nil {
 				if strings.HasSuffix(err.Error(), "context canceled") {
 					return nil
 				} else if response != nil {
-					printResult(r.Config.Core.Output, response, nil)
+					printResult(r.Config.Core.Output, response, outputOptions{Theme: colorThemeFor(r.Config)})
 				}
 				return err
 			}
 
-			var filterKeys []string
-			for _, arg := range apiArgs {
-				if strings.HasPrefix(arg, "filter=") {
-					for _, filterKey := range strings.Split(strings.Split(arg, "=")[1], ",") {
-						if len(strings.TrimSpace(filterKey)) > 0 {
-							filterKeys = append(filterKeys, strings.TrimSpace(filterKey))
-						}
-					}
+			if query := fakeArgs["query"]; len(query) > 0 && len(response) > 0 {
+				response, err = queryResponse(response, query)
+				if err != nil {
+					return err
 				}
 			}
 
 			if len(response) > 0 {
-				printResult(r.Config.Core.Output, response, filterKeys)
+				printResult(outputType, response, options)
 			}
 
 			return nil
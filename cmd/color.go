@@ -0,0 +1,137 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+// colorTheme holds the ANSI colour codes of the output, the codes are all two
+// digits long so that coloured cells keep the alignment of the column output
+type colorTheme struct {
+	Key     string
+	String  string
+	Number  string
+	Literal string
+	Good    string
+	Pending string
+	Bad     string
+	Neutral string
+	Error   string
+	Name    string
+	Async   string
+	Param   string
+	Type    string
+	Changed string
+}
+
+var noColors = &colorTheme{}
+
+var colorThemes = map[string]*colorTheme{
+	config.THEME_DEFAULT: {
+		Key: "34", String: "32", Number: "36", Literal: "35",
+		Good: "32", Pending: "33", Bad: "31", Neutral: "39", Error: "31",
+		Name: "34", Async: "35", Param: "36", Type: "32", Changed: "07",
+	},
+	config.THEME_VIVID: {
+		Key: "94", String: "92", Number: "96", Literal: "95",
+		Good: "92", Pending: "93", Bad: "91", Neutral: "39", Error: "91",
+		Name: "94", Async: "95", Param: "96", Type: "92", Changed: "07",
+	},
+	config.THEME_NONE: noColors,
+}
+
+// stateFields are the response fields whose values are coloured by state
+var stateFields = map[string]bool{
+	"allocationstate": true,
+	"powerstate":      true,
+	"resourcestate":   true,
+	"state":           true,
+	"status":          true,
+}
+
+var goodStates = map[string]bool{
+	"active": true, "backedup": true, "connected": true, "enabled": true, "implemented": true,
+	"ready": true, "running": true, "up": true,
+}
+
+var pendingStates = map[string]bool{
+	"allocated": true, "allocating": true, "creating": true, "destroying": true, "expunging": true,
+	"maintenance": true, "migrating": true, "pending": true, "prepareformaintenance": true,
+	"setup": true, "starting": true, "stopping": true,
+}
+
+var badStates = map[string]bool{
+	"alert": true, "destroyed": true, "disabled": true, "disconnected": true, "down": true,
+	"error": true, "expunged": true, "failed": true, "stopped": true,
+}
+
+// colorThemeFor returns the configured colour theme, colours are disabled when
+// the output is not a terminal or the NO_COLOR environment variable is set
+func colorThemeFor(cfg *config.Config) *colorTheme {
+	if cfg == nil || cfg.Core == nil || len(os.Getenv("NO_COLOR")) > 0 || !isTerminal(outputWriter) {
+		return noColors
+	}
+	if theme, ok := colorThemes[cfg.Core.Theme]; ok {
+		return theme
+	}
+	return colorThemes[config.THEME_DEFAULT]
+}
+
+func paint(color string, text string) string {
+	if len(color) == 0 {
+		return text
+	}
+	return "\033[" + color + "m" + text + "\033[0m"
+}
+
+func stateColor(theme *colorTheme, state string) string {
+	state = strings.ToLower(state)
+	switch {
+	case goodStates[state]:
+		return theme.Good
+	case pendingStates[state]:
+		return theme.Pending
+	case badStates[state]:
+		return theme.Bad
+	}
+	return theme.Neutral
+}
+
+// colorField colours the printed value of a state field
+func (p *printer) colorField(field string, text string) string {
+	return p.colorFieldAs(field, text, text)
+}
+
+// colorFieldAs colours a part of the printed value of a state field, such as a
+// truncated value, by the state of the whole value
+func (p *printer) colorFieldAs(field string, value string, text string) string {
+	if !stateFields[strings.ToLower(field)] {
+		return text
+	}
+	return paint(stateColor(p.theme, value), text)
+}
+
+// FormatError returns the error message as printed by the shell and the CLI
+func FormatError(cfg *config.Config, err error) string {
+	return paint(colorThemeFor(cfg).Error, fmt.Sprint("🙈 Error: ", err))
+}
@@ -65,13 +65,18 @@ CloudMonkey (cmk) 🐵 is a command line interface for Apache CloudStack.
 Allowed flags:
   -h        Show this help message or API doc when specified after an API
   -v        Print version
-  -o        API response output format: json, text, table, column, csv
+  -o        API response output format: json, ndjson, text, table, column, csv,
+            markdown, html, yaml, template
   -p        Server profile
+  -q        JMESPath-like query expression to apply on the API response
   -d        Enable debug mode
   -c        Different config file path
   -u	    CloudStack's API endpoint URL
   -s	    CloudStack user's secret Key
   -k	    CloudStack user's API Key
+  --yes     Assume yes for confirmation prompts of destructive APIs
+  --output-file
+            Write the formatted output to a file instead of stdout
 
 Default commands:
 %s
@@ -0,0 +1,128 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+	"golang.org/x/term"
+)
+
+// ReadInput reads a line of user input, the interactive shell replaces it
+// with one that uses its own line reader
+var ReadInput = func(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line), err
+}
+
+// findListAPI finds the list API for the noun of an API, for example listDomains for deleteDomain
+func findListAPI(r *Request, api *config.API) *config.API {
+	noun := strings.ToLower(api.Noun)
+	candidates := []string{noun + "s", noun + "es", noun}
+	if strings.HasSuffix(noun, "y") {
+		candidates = append([]string{noun[:len(noun)-1] + "ies"}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if listAPI := r.Config.GetCache()["list"+candidate]; listAPI != nil {
+			return listAPI
+		}
+	}
+	return nil
+}
+
+// resolveTargetName finds the name of the resource an API acts upon
+func resolveTargetName(r *Request, listAPI *config.API, id string) string {
+	if listAPI == nil || !listAPI.HasArg("id") {
+		return ""
+	}
+	args := []string{"id=" + id}
+	if listAPI.HasArg("listall") {
+		args = append(args, "listall=true")
+	}
+	response, err := NewAPIRequest(r, listAPI.Name, args, false)
+	if err != nil {
+		config.Debug("Failed to resolve name for id ", id, ": ", err)
+		return ""
+	}
+	for _, v := range response {
+		items, ok := v.([]interface{})
+		if !ok || len(items) == 0 {
+			continue
+		}
+		if row, ok := items[0].(map[string]interface{}); ok {
+			for _, key := range []string{"name", "displayname", "username"} {
+				if name, ok := row[key].(string); ok && len(name) > 0 {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// confirmAPI asks the user to confirm a destructive API call
+func confirmAPI(r *Request, api *config.API, args []string) error {
+	if !r.Config.HasShell && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("refusing to run %s without confirmation, use --yes to confirm", api.Name)
+	}
+
+	var targets []string
+	var otherArgs []string
+	listAPI := findListAPI(r, api)
+	spinner := r.Config.StartSpinner("looking up target, please wait...")
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) == 2 && (parts[0] == "id" || parts[0] == "ids") {
+			for _, id := range strings.Split(parts[1], ",") {
+				if name := resolveTargetName(r, listAPI, id); len(name) > 0 {
+					targets = append(targets, fmt.Sprintf("%s '%s' (%s)", api.Noun, name, id))
+				} else {
+					targets = append(targets, fmt.Sprintf("%s %s", api.Noun, id))
+				}
+			}
+			continue
+		}
+		otherArgs = append(otherArgs, arg)
+	}
+	r.Config.StopSpinner(spinner)
+
+	message := fmt.Sprintf("⚠️  About to run %s", api.Name)
+	if len(targets) > 0 {
+		message += " on " + strings.Join(targets, ", ")
+	}
+	if len(otherArgs) > 0 {
+		message += " with " + strings.Join(otherArgs, " ")
+	}
+	fmt.Println(message)
+
+	answer, err := ReadInput("Continue? [y/N]: ")
+	if err != nil {
+		return errors.New("aborted " + api.Name)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		return errors.New("aborted " + api.Name)
+	}
+	return nil
+}
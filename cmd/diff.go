@@ -0,0 +1,425 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// defaultDiffKeys are the fields used to match list items, in order of preference
+var defaultDiffKeys = []string{"id", "name"}
+
+// diffItem is a flattened list item along with the original item
+type diffItem struct {
+	item   map[string]interface{}
+	fields map[string]interface{}
+}
+
+// diffChange is a pair of matched items whose fields differ
+type diffChange struct {
+	left    diffItem
+	right   diffItem
+	changed []string
+}
+
+type diffResult struct {
+	added     []diffItem
+	removed   []diffItem
+	changed   []diffChange
+	unchanged int
+}
+
+// parseDiffArgs returns the options, the sources to compare and the API with its args
+func parseDiffArgs(r *Request) (string, []string, []string, []string, error) {
+	format := config.TEXT
+	var keys []string
+	args := r.Args
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if len(args) < 2 {
+			return "", nil, nil, nil, fmt.Errorf("please provide a value for %s", args[0])
+		}
+		switch args[0] {
+		case "-o":
+			if args[1] != config.TEXT && args[1] != config.JSON {
+				return "", nil, nil, nil, fmt.Errorf("invalid diff output %s, supported values: text, json", args[1])
+			}
+			format = args[1]
+		case "-k":
+			keys = splitFakeArgList(args[1])
+		default:
+			return "", nil, nil, nil, fmt.Errorf("unknown diff option %s", args[0])
+		}
+		args = args[2:]
+	}
+
+	var sources []string
+	for len(args) > 0 && len(sources) < 2 {
+		if !strings.HasPrefix(args[0], "@") && !config.CheckIfValuePresent(config.GetProfiles(), args[0]) {
+			break
+		}
+		sources = append(sources, args[0])
+		args = args[1:]
+	}
+	if len(sources) == 0 {
+		return "", nil, nil, nil, errors.New("please provide a profile or a @file.json to compare with")
+	}
+	if len(sources) == 1 {
+		sources = append([]string{r.Config.Core.ProfileName}, sources...)
+	}
+	if len(args) == 0 {
+		return "", nil, nil, nil, errors.New("please provide an API to compare")
+	}
+	return format, keys, sources, args, nil
+}
+
+// findDiffAPI returns the API of a command line such as list serviceofferings and its args
+func findDiffAPI(cfg *config.Config, args []string) (*config.API, []string, error) {
+	apiName := strings.ToLower(args[0])
+	apiArgs := args[1:]
+	if cfg.GetCache()[apiName] == nil && len(args) > 1 {
+		apiName = strings.ToLower(strings.Join(args[:2], ""))
+		apiArgs = args[2:]
+	}
+	api := cfg.GetCache()[apiName]
+	if api == nil {
+		return nil, nil, errors.New("unknown API requested")
+	}
+	if !config.CheckIfValuePresent(config.ReadOnlyVerbs, strings.ToLower(api.Verb)) {
+		return nil, nil, fmt.Errorf("only read-only APIs such as list and get APIs can be compared, not %s", api.Name)
+	}
+	return api, apiArgs, nil
+}
+
+// loadDiffFile reads a response saved as JSON, the saved file can be the output of
+// cmk with the json output format or the raw API response
+func loadDiffFile(path string) (map[string]interface{}, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		return map[string]interface{}{"items": v}, nil
+	case map[string]interface{}:
+		if response := getResponseData(v); response != nil && len(v) == 1 {
+			return response, nil
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("%s does not contain a JSON object or list", path)
+}
+
+// fetchDiffResponse runs the API against a profile or loads the response from a @file
+func fetchDiffResponse(r *Request, source string, api *config.API, args []string) (map[string]interface{}, error) {
+	if strings.HasPrefix(source, "@") {
+		return loadDiffFile(source[1:])
+	}
+	if source != r.Config.Core.ProfileName {
+		activeProfile, profileName := r.Config.ActiveProfile, r.Config.Core.ProfileName
+		r.Config.LoadProfile(source)
+		defer func() {
+			r.Config.ActiveProfile = activeProfile
+			r.Config.Core.ProfileName = profileName
+		}()
+	}
+	apiArgs := mergeDefaultArgs(r.Config.GetDefaultArgs(api), args)
+	apiArgs, _ = splitFakeArgs(api, apiArgs)
+
+	spinner := r.Config.StartSpinner("fetching " + api.Name + " from " + source + ", please wait...")
+	response, err := NewAPIRequest(r, api.Name, apiArgs, false)
+	r.Config.StopSpinner(spinner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	return response, nil
+}
+
+// diffItems returns the flattened items of the first list in a response, or the
+// whole response as a single item when it has no list
+func diffItems(response map[string]interface{}, filter []string) ([]diffItem, bool) {
+	var items []interface{}
+	hasList := false
+	for _, key := range orderedKeys(response) {
+		if list, ok := response[key].([]interface{}); ok {
+			items, hasList = list, true
+			break
+		}
+	}
+	if !hasList {
+		object := make(map[string]interface{})
+		for key, value := range response {
+			if key != "count" {
+				object[key] = value
+			}
+		}
+		if len(object) > 0 {
+			items = []interface{}{object}
+		}
+	}
+
+	var diffItems []diffItem
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		row := make(map[string]interface{})
+		flattenRow("", object, row)
+		if len(filter) > 0 {
+			for field := range row {
+				selected := false
+				for _, key := range filter {
+					if field == key || strings.HasPrefix(field, key+".") {
+						selected = true
+						break
+					}
+				}
+				if !selected {
+					delete(row, field)
+				}
+			}
+		}
+		diffItems = append(diffItems, diffItem{item: object, fields: row})
+	}
+	return diffItems, hasList
+}
+
+func (item diffItem) value(field string) string {
+	value, ok := item.fields[field]
+	if !ok {
+		return ""
+	}
+	return jsonify(value, "")
+}
+
+// label returns the name or id that identifies an item to the user
+func (item diffItem) label() string {
+	for _, field := range []string{"name", "displayname", "id"} {
+		if value := item.value(field); len(value) > 0 {
+			return value
+		}
+	}
+	return "item"
+}
+
+// diffFields returns the fields that differ between two items, skipping the
+// fields that are not expected to match between the sources
+func diffFields(left diffItem, right diffItem, skip []string) []string {
+	fields := make(map[string]interface{})
+	for field := range left.fields {
+		fields[field] = nil
+	}
+	for field := range right.fields {
+		fields[field] = nil
+	}
+	var changed []string
+	for _, field := range orderedKeys(fields) {
+		_, inLeft := left.fields[field]
+		_, inRight := right.fields[field]
+		if indexOf(skip, field) >= 0 || (inLeft == inRight && left.value(field) == right.value(field)) {
+			continue
+		}
+		changed = append(changed, field)
+	}
+	return changed
+}
+
+// diffResponses matches the items of two lists by the first of the keys they share,
+// items matched by a later key such as name are not compared by the earlier keys
+func diffResponses(left []diffItem, right []diffItem, keys []string) diffResult {
+	matches := make([]int, len(left))
+	matchedBy := make([]int, len(left))
+	matched := make([]bool, len(right))
+	for i := range matches {
+		matches[i] = -1
+	}
+	for keyIdx, key := range keys {
+		index := make(map[string]int)
+		for j := len(right) - 1; j >= 0; j-- {
+			if value := right[j].value(key); !matched[j] && len(value) > 0 {
+				index[value] = j
+			}
+		}
+		for i := range left {
+			if matches[i] >= 0 {
+				continue
+			}
+			if j, ok := index[left[i].value(key)]; ok && !matched[j] {
+				matches[i], matchedBy[i], matched[j] = j, keyIdx, true
+			}
+		}
+	}
+
+	var result diffResult
+	for i, j := range matches {
+		if j < 0 {
+			result.removed = append(result.removed, left[i])
+			continue
+		}
+		changed := diffFields(left[i], right[j], keys[:matchedBy[i]])
+		if len(changed) == 0 {
+			result.unchanged++
+			continue
+		}
+		result.changed = append(result.changed, diffChange{left: left[i], right: right[j], changed: changed})
+	}
+	for j := range right {
+		if !matched[j] {
+			result.added = append(result.added, right[j])
+		}
+	}
+	return result
+}
+
+func printDiffText(result diffResult, sources []string, theme *colorTheme) {
+	fmt.Fprintln(outputWriter, paint(theme.Bad, "--- "+sources[0]))
+	fmt.Fprintln(outputWriter, paint(theme.Good, "+++ "+sources[1]))
+	for _, item := range result.removed {
+		fmt.Fprintln(outputWriter, paint(theme.Bad, "- "+item.label()))
+	}
+	for _, item := range result.added {
+		fmt.Fprintln(outputWriter, paint(theme.Good, "+ "+item.label()))
+	}
+	for _, change := range result.changed {
+		fmt.Fprintln(outputWriter, paint(theme.Pending, "~ "+change.left.label()))
+		for _, field := range change.changed {
+			oldValue, newValue := change.left.value(field), change.right.value(field)
+			if _, ok := change.left.fields[field]; !ok {
+				oldValue = "(none)"
+			}
+			if _, ok := change.right.fields[field]; !ok {
+				newValue = "(none)"
+			}
+			fmt.Fprintf(outputWriter, "    %s: %s -> %s\n", paint(theme.Key, field), paint(theme.Bad, oldValue), paint(theme.Good, newValue))
+		}
+	}
+	fmt.Fprintf(outputWriter, "%d added, %d removed, %d changed, %d unchanged\n", len(result.added), len(result.removed), len(result.changed), result.unchanged)
+}
+
+func diffJSON(result diffResult, sources []string) map[string]interface{} {
+	items := func(diffItems []diffItem) []interface{} {
+		list := []interface{}{}
+		for _, item := range diffItems {
+			list = append(list, item.item)
+		}
+		return list
+	}
+	changed := []interface{}{}
+	for _, change := range result.changed {
+		fields := make(map[string]interface{})
+		for _, field := range change.changed {
+			fields[field] = map[string]interface{}{
+				sources[0]: change.left.fields[field],
+				sources[1]: change.right.fields[field],
+			}
+		}
+		item := map[string]interface{}{"fields": fields}
+		for _, key := range []string{"id", "name"} {
+			if value, ok := change.left.fields[key]; ok {
+				item[key] = value
+			}
+		}
+		changed = append(changed, item)
+	}
+	return map[string]interface{}{
+		"added":     items(result.added),
+		"removed":   items(result.removed),
+		"changed":   changed,
+		"unchanged": float64(result.unchanged),
+	}
+}
+
+func init() {
+	AddCommand(&Command{
+		Name: "diff",
+		Help: "Compares the response of an API between two profiles or with a saved JSON file",
+		Handle: func(r *Request) error {
+			if len(r.Args) == 0 || r.Args[len(r.Args)-1] == "-h" {
+				fmt.Println("Usage: diff [-o text|json] [-k <fields>] <profile or @file.json> [<profile or @file.json>] <API> [args], for example: diff staging production list serviceofferings filter=name,cpunumber,memory")
+				fmt.Println("With a single profile or file the response is compared with the active profile. List items are matched by id, then by name unless -k provides the fields to match by.")
+				return nil
+			}
+			format, keys, sources, args, err := parseDiffArgs(r)
+			if err != nil {
+				return err
+			}
+			api, apiArgs, err := findDiffAPI(r.Config, args)
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				keys = defaultDiffKeys
+			}
+			_, fakeArgs := splitFakeArgs(api, apiArgs)
+			var where wherePredicate
+			if len(fakeArgs["where"]) > 0 {
+				if where, err = compileWhere(fakeArgs["where"]); err != nil {
+					return err
+				}
+			}
+			config.Debug("Comparing ", api.Name, " between ", sources)
+
+			var responses [][]diffItem
+			isList := false
+			for _, source := range sources {
+				response, err := fetchDiffResponse(r, source, api, apiArgs)
+				if err != nil {
+					if strings.HasSuffix(err.Error(), "context canceled") {
+						return nil
+					}
+					return err
+				}
+				response = whereResponse(response, where)
+				items, hasList := diffItems(response, splitFakeArgList(fakeArgs["filter"]))
+				responses = append(responses, items)
+				isList = isList || hasList
+			}
+
+			var result diffResult
+			if isList || len(responses[0]) != 1 || len(responses[1]) != 1 {
+				result = diffResponses(responses[0], responses[1], keys)
+			} else if changed := diffFields(responses[0][0], responses[1][0], nil); len(changed) > 0 {
+				result.changed = []diffChange{{left: responses[0][0], right: responses[1][0], changed: changed}}
+			} else {
+				result.unchanged = 1
+			}
+			theme := colorThemeFor(r.Config)
+			if format == config.JSON {
+				printResult(config.JSON, diffJSON(result, sources), outputOptions{Theme: theme})
+				return nil
+			}
+			printDiffText(result, sources, theme)
+			return nil
+		},
+	})
+}
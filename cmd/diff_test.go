@@ -0,0 +1,159 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func diffTestItems(response map[string]interface{}, filter []string) []diffItem {
+	items, _ := diffItems(response, filter)
+	return items
+}
+
+func TestDiffItems(t *testing.T) {
+	response := map[string]interface{}{
+		"count": float64(2),
+		"serviceoffering": []interface{}{
+			map[string]interface{}{"id": "1", "name": "small", "cpunumber": float64(1), "details": map[string]interface{}{"a": "b"}},
+			"plain",
+			map[string]interface{}{"id": "2", "name": "large", "cpunumber": float64(4)},
+		},
+	}
+	items, hasList := diffItems(response, nil)
+	if !hasList || len(items) != 2 || items[0].value("details.a") != "b" || items[1].value("cpunumber") != "4" {
+		t.Errorf("diffItems returned %v, %v", items, hasList)
+	}
+
+	items = diffTestItems(response, []string{"name", "details"})
+	if fields := orderedKeys(items[0].fields); !reflect.DeepEqual(fields, []string{"name", "details.a"}) {
+		t.Errorf("diffItems with a filter returned the fields %v", fields)
+	}
+
+	items, hasList = diffItems(map[string]interface{}{"count": float64(1), "name": "zone1"}, nil)
+	if hasList || len(items) != 1 || !reflect.DeepEqual(items[0].fields, map[string]interface{}{"name": "zone1"}) {
+		t.Errorf("diffItems for an object returned %v, %v", items, hasList)
+	}
+
+	if items, _ := diffItems(map[string]interface{}{"count": float64(0)}, nil); len(items) != 0 {
+		t.Errorf("diffItems for an empty response returned %v", items)
+	}
+}
+
+func TestDiffResponses(t *testing.T) {
+	left := diffTestItems(map[string]interface{}{"offering": []interface{}{
+		map[string]interface{}{"id": "1", "name": "small", "cpunumber": float64(1)},
+		map[string]interface{}{"id": "2", "name": "medium", "cpunumber": float64(2)},
+		map[string]interface{}{"id": "3", "name": "large", "cpunumber": float64(4), "tags": "ssd"},
+		map[string]interface{}{"id": "4", "name": "old", "cpunumber": float64(8)},
+	}}, nil)
+	right := diffTestItems(map[string]interface{}{"offering": []interface{}{
+		map[string]interface{}{"id": "1", "name": "small", "cpunumber": float64(1)},
+		map[string]interface{}{"id": "b", "name": "medium", "cpunumber": float64(2)},
+		map[string]interface{}{"id": "3", "name": "large", "cpunumber": float64(8)},
+		map[string]interface{}{"id": "5", "name": "new", "cpunumber": float64(16)},
+	}}, nil)
+	result := diffResponses(left, right, defaultDiffKeys)
+
+	var out bytes.Buffer
+	previous := SetOutputWriter(&out)
+	printDiffText(result, []string{"staging", "production"}, noColors)
+	SetOutputWriter(previous)
+	expected := `--- staging
++++ production
+- old
++ new
+~ large
+    cpunumber: 4 -> 8
+    tags: ssd -> (none)
+1 added, 1 removed, 1 changed, 2 unchanged
+`
+	if out.String() != expected {
+		t.Errorf("printDiffText printed\n%s\nexpected\n%s", out.String(), expected)
+	}
+
+	result = diffResponses(left, right, []string{"id"})
+	if len(result.added) != 2 || len(result.removed) != 2 || len(result.changed) != 1 || result.unchanged != 1 {
+		t.Errorf("diffResponses by id returned %d added, %d removed, %d changed, %d unchanged",
+			len(result.added), len(result.removed), len(result.changed), result.unchanged)
+	}
+
+	json := diffJSON(diffResponses(left, right, defaultDiffKeys), []string{"staging", "production"})
+	changed := json["changed"].([]interface{})[0].(map[string]interface{})
+	expectedFields := map[string]interface{}{
+		"cpunumber": map[string]interface{}{"staging": float64(4), "production": float64(8)},
+		"tags":      map[string]interface{}{"staging": "ssd", "production": nil},
+	}
+	if changed["name"] != "large" || !reflect.DeepEqual(changed["fields"], expectedFields) || json["unchanged"] != float64(2) {
+		t.Errorf("diffJSON returned %v", json)
+	}
+}
+
+func TestDiffResponsesDuplicateKeys(t *testing.T) {
+	left := diffTestItems(map[string]interface{}{"vm": []interface{}{
+		map[string]interface{}{"name": "web", "state": "Running"},
+		map[string]interface{}{"name": "web", "state": "Stopped"},
+	}}, nil)
+	right := diffTestItems(map[string]interface{}{"vm": []interface{}{
+		map[string]interface{}{"name": "web", "state": "Running"},
+	}}, nil)
+	result := diffResponses(left, right, defaultDiffKeys)
+	if len(result.removed) != 1 || result.removed[0].value("state") != "Stopped" || result.unchanged != 1 || len(result.added) != 0 {
+		t.Errorf("diffResponses with duplicate names returned %+v", result)
+	}
+}
+
+func TestLoadDiffFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content  string
+		expected map[string]interface{}
+	}{
+		{`{"listzonesresponse": {"count": 1, "zone": [{"name": "zone1"}]}}`, map[string]interface{}{"count": float64(1), "zone": []interface{}{map[string]interface{}{"name": "zone1"}}}},
+		{`{"count": 1, "zone": [{"name": "zone1"}]}`, map[string]interface{}{"count": float64(1), "zone": []interface{}{map[string]interface{}{"name": "zone1"}}}},
+		{`[{"name": "zone1"}]`, map[string]interface{}{"items": []interface{}{map[string]interface{}{"name": "zone1"}}}},
+	}
+	for idx, test := range tests {
+		path := filepath.Join(dir, "response.json")
+		if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		response, err := loadDiffFile(path)
+		if err != nil {
+			t.Errorf("loadDiffFile %d failed: %v", idx, err)
+			continue
+		}
+		if !reflect.DeepEqual(response, test.expected) {
+			t.Errorf("loadDiffFile(%s) = %v, expected %v", test.content, response, test.expected)
+		}
+	}
+	for _, content := range []string{`"zone1"`, `{"count": `} {
+		path := filepath.Join(dir, "invalid.json")
+		os.WriteFile(path, []byte(content), 0600)
+		if _, err := loadDiffFile(path); err == nil {
+			t.Errorf("loadDiffFile(%s) did not fail", content)
+		}
+	}
+	if _, err := loadDiffFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("loadDiffFile did not fail for a missing file")
+	}
+}
@@ -42,9 +42,10 @@ func init() {
 				return errors.New("unknown command or API requested")
 			}
 
-			fmt.Printf("\033[34m%s\033[0m: %s\n", api.Name, api.Description)
+			theme := colorThemeFor(r.Config)
+			fmt.Printf("%s: %s\n", paint(theme.Name, api.Name), api.Description)
 			if api.Async {
-				fmt.Println("This API is \033[35masynchronous\033[0m.")
+				fmt.Printf("This API is %s.\n", paint(theme.Async, "asynchronous"))
 			}
 			if len(api.RequiredArgs) > 0 {
 				fmt.Printf("Required params: ")
@@ -53,6 +54,9 @@ func init() {
 				}
 				fmt.Println()
 			}
+			if defaultArgs := r.Config.GetDefaultArgs(api); len(defaultArgs) > 0 {
+				fmt.Printf("Default args: %s\n", strings.Join(defaultArgs, " "))
+			}
 			if len(api.Args) > 0 {
 				fmt.Printf("%-24s %-8s %s\n", "API Params", "Type", "Description")
 				fmt.Printf("%-24s %-8s %s\n", "==========", "====", "===========")
@@ -61,7 +65,7 @@ func init() {
 				if arg.Type == config.FAKE {
 					continue
 				}
-				fmt.Printf("\033[36m%-24s\033[0m \033[32m%-8s\033[0m ", strings.Replace(arg.Name, "=", "", -1), arg.Type)
+				fmt.Printf("%s %s ", paint(theme.Param, fmt.Sprintf("%-24s", strings.Replace(arg.Name, "=", "", -1))), paint(theme.Type, fmt.Sprintf("%-8s", arg.Type)))
 				info := []rune(arg.Description)
 				for i, r := range info {
 					fmt.Printf("%s", string(r))
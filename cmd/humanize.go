@@ -0,0 +1,239 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+// sizeFields maps the response fields holding sizes to the unit they are returned in
+var sizeFields = map[string]float64{
+	"bytesreceived":     1,
+	"bytessent":         1,
+	"disksizeallocated": 1,
+	"disksizetotal":     1,
+	"disksizeused":      1,
+	"memoryallocated":   1,
+	"memorytotal":       1,
+	"memoryused":        1,
+	"physicalsize":      1,
+	"size":              1,
+	"virtualsize":       1,
+	"diskkbsread":       1 << 10,
+	"diskkbswrite":      1 << 10,
+	"memoryintfreekbs":  1 << 10,
+	"memorykbs":         1 << 10,
+	"memorytargetkbs":   1 << 10,
+	"networkkbsread":    1 << 10,
+	"networkkbswrite":   1 << 10,
+	"maxmemory":         1 << 20,
+	"memory":            1 << 20,
+	"minmemory":         1 << 20,
+	"disksize":          1 << 30,
+	"maxsize":           1 << 30,
+	"minsize":           1 << 30,
+}
+
+// durationFields maps the response fields holding durations to the unit they are returned in
+var durationFields = map[string]time.Duration{
+	"interval":     time.Second,
+	"responsetime": time.Millisecond,
+	"timeout":      time.Second,
+	"uptime":       time.Second,
+}
+
+var sizeUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+// timestampLayouts are the layouts of untyped string fields that are treated as timestamps
+var timestampLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339,
+}
+
+func formatSize(bytes float64) string {
+	unit := 0
+	for bytes >= 1024 && unit < len(sizeUnits)-1 {
+		bytes /= 1024
+		unit++
+	}
+	return strings.TrimSuffix(strconv.FormatFloat(bytes, 'f', 1, 64), ".0") + " " + sizeUnits[unit]
+}
+
+func formatDuration(duration time.Duration) string {
+	if duration < time.Second {
+		return duration.String()
+	}
+	duration = duration.Round(time.Second)
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	// the largest unit and the one after it are shown, such as 1h 5m but not 1h 5s
+	var parts []string
+	shown := 0
+	for _, unit := range units {
+		if count := duration / unit.size; count > 0 || shown > 0 {
+			if count > 0 {
+				parts = append(parts, fmt.Sprintf("%d%s", count, unit.suffix))
+			}
+			duration -= count * unit.size
+			if shown++; shown == 2 {
+				break
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func plural(count int, unit string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// formatRelativeTime describes a timestamp relative to now, such as 3 days ago
+func formatRelativeTime(timestamp time.Time, now time.Time) string {
+	diff := now.Sub(timestamp)
+	future := diff < 0
+	if future {
+		diff = -diff
+	}
+	var text string
+	switch {
+	case diff < time.Minute:
+		return "just now"
+	case diff < time.Hour:
+		text = plural(int(diff/time.Minute), "minute")
+	case diff < 24*time.Hour:
+		text = plural(int(diff/time.Hour), "hour")
+	case diff < 30*24*time.Hour:
+		text = plural(int(diff/(24*time.Hour)), "day")
+	case diff < 365*24*time.Hour:
+		text = plural(int(diff/(30*24*time.Hour)), "month")
+	default:
+		text = plural(int(diff/(365*24*time.Hour)), "year")
+	}
+	if future {
+		return "in " + text
+	}
+	return text + " ago"
+}
+
+func parseTimestamp(value string, fieldType string) (time.Time, bool) {
+	if strings.EqualFold(fieldType, "date") {
+		return parseSortDate(value)
+	}
+	if len(fieldType) > 0 {
+		return time.Time{}, false
+	}
+	for _, layout := range timestampLayouts {
+		if timestamp, err := time.Parse(layout, value); err == nil {
+			return timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// humanizeValue formats sizes, durations and timestamps of a response field for reading
+func humanizeValue(field string, value interface{}, types map[string]string, timeFormat string) interface{} {
+	fieldType := types[field]
+	name := field
+	if match := aggregateRegex.FindStringSubmatch(field); match != nil && match[1] != "count" {
+		name = match[2]
+	}
+	if idx := strings.LastIndex(field, "."); idx >= 0 {
+		name = field[idx+1:]
+	}
+	switch v := value.(type) {
+	case float64:
+		if humanized, ok := humanizeNumber(name, v); ok {
+			return humanized
+		}
+	case json.Number:
+		if number, err := v.Float64(); err == nil {
+			if humanized, ok := humanizeNumber(name, number); ok {
+				return humanized
+			}
+		}
+	case string:
+		timestamp, ok := parseTimestamp(v, fieldType)
+		if !ok {
+			return value
+		}
+		if timeFormat == config.TIME_LOCAL {
+			return timestamp.Local().Format("2006-01-02 15:04:05 MST")
+		}
+		return formatRelativeTime(timestamp, time.Now())
+	}
+	return value
+}
+
+// humanizeNumber formats the number of a size or duration field
+func humanizeNumber(name string, value float64) (string, bool) {
+	if unit, ok := sizeFields[name]; ok {
+		return formatSize(value * unit), true
+	}
+	if unit, ok := durationFields[name]; ok {
+		return formatDuration(time.Duration(value * float64(unit))), true
+	}
+	return "", false
+}
+
+func humanizeRow(row map[string]interface{}, types map[string]string, timeFormat string) map[string]interface{} {
+	humanized := make(map[string]interface{}, len(row))
+	for field, value := range row {
+		humanized[field] = humanizeValue(field, value, types, timeFormat)
+	}
+	return humanized
+}
+
+// humanizeResponse formats the top level fields and list items of a response for reading
+func humanizeResponse(response map[string]interface{}, types map[string]string, timeFormat string) map[string]interface{} {
+	humanized := make(map[string]interface{}, len(response))
+	for key, value := range response {
+		switch v := value.(type) {
+		case []interface{}:
+			items := make([]interface{}, len(v))
+			for idx, item := range v {
+				if row, ok := item.(map[string]interface{}); ok {
+					items[idx] = humanizeRow(row, types, timeFormat)
+				} else {
+					items[idx] = item
+				}
+			}
+			humanized[key] = items
+		case map[string]interface{}:
+			humanized[key] = humanizeRow(v, types, timeFormat)
+		default:
+			humanized[key] = humanizeValue(key, value, types, timeFormat)
+		}
+	}
+	return humanized
+}
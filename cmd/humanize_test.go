@@ -0,0 +1,167 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes    float64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1 KiB"},
+		{1536, "1.5 KiB"},
+		{1048575, "1024 KiB"},
+		{5 * 1 << 30, "5 GiB"},
+		{2.26 * (1 << 40), "2.3 TiB"},
+		{3 * (1 << 50), "3 PiB"},
+		{4096 * (1 << 50), "4096 PiB"},
+	}
+	for _, test := range tests {
+		if size := formatSize(test.bytes); size != test.expected {
+			t.Errorf("formatSize(%v) = %s, expected %s", test.bytes, size, test.expected)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{250 * time.Millisecond, "250ms"},
+		{time.Second, "1s"},
+		{59600 * time.Millisecond, "1m"},
+		{90 * time.Second, "1m 30s"},
+		{time.Hour + time.Second, "1h"},
+		{time.Hour + 5*time.Minute + 30*time.Second, "1h 5m"},
+		{26*time.Hour + 30*time.Minute, "1d 2h"},
+		{24*time.Hour + 30*time.Second, "1d"},
+		{400 * 24 * time.Hour, "400d"},
+	}
+	for _, test := range tests {
+		if duration := formatDuration(test.duration); duration != test.expected {
+			t.Errorf("formatDuration(%v) = %s, expected %s", test.duration, duration, test.expected)
+		}
+	}
+}
+
+func TestFormatRelativeTime(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		timestamp time.Time
+		expected  string
+	}{
+		{now.Add(-30 * time.Second), "just now"},
+		{now.Add(30 * time.Second), "just now"},
+		{now.Add(-time.Minute), "1 minute ago"},
+		{now.Add(-59 * time.Minute), "59 minutes ago"},
+		{now.Add(-5 * time.Hour), "5 hours ago"},
+		{now.Add(-24 * time.Hour), "1 day ago"},
+		{now.Add(-45 * 24 * time.Hour), "1 month ago"},
+		{now.Add(-800 * 24 * time.Hour), "2 years ago"},
+		{now.Add(3 * time.Hour), "in 3 hours"},
+		{now.Add(10 * 24 * time.Hour), "in 10 days"},
+	}
+	for _, test := range tests {
+		if text := formatRelativeTime(test.timestamp, now); text != test.expected {
+			t.Errorf("formatRelativeTime(%v) = %s, expected %s", test.timestamp, text, test.expected)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		value     string
+		fieldType string
+		ok        bool
+	}{
+		{"2024-01-02T10:00:00+0000", "", true},
+		{"2024-01-02T10:00:00Z", "", true},
+		{"2024-01-02", "", false},
+		{"2024-01-02", "date", true},
+		{"2024-01-02 10:00:00", "Date", true},
+		{"2024-01-02T10:00:00+0000", "string", false},
+		{"web-1", "", false},
+		{"web-1", "date", false},
+	}
+	for _, test := range tests {
+		if _, ok := parseTimestamp(test.value, test.fieldType); ok != test.ok {
+			t.Errorf("parseTimestamp(%q, %q) = %v, expected %v", test.value, test.fieldType, ok, test.ok)
+		}
+	}
+}
+
+func TestHumanizeValue(t *testing.T) {
+	tests := []struct {
+		field    string
+		value    interface{}
+		expected interface{}
+	}{
+		{"memory", float64(2048), "2 GiB"},
+		{"memorykbs", float64(1536), "1.5 MiB"},
+		{"size", float64(1 << 30), "1 GiB"},
+		{"disksize", float64(20), "20 GiB"},
+		{"details.memory", float64(512), "512 MiB"},
+		{"avg(memory)", float64(3072), "3 GiB"},
+		{"avg(memory)", json.Number("1536.5"), "1.5 GiB"},
+		{"count", float64(2048), float64(2048)},
+		{"cpunumber", float64(4), float64(4)},
+		{"uptime", float64(3700), "1h 1m"},
+		{"responsetime", float64(1500), "2s"},
+		{"memory", "2048", "2048"},
+		{"name", "web-1", "web-1"},
+		{"created", "2024-01-02T10:00:00+0000", time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC).Local().Format("2006-01-02 15:04:05 MST")},
+		{"ha", true, true},
+		{"nic", []interface{}{}, []interface{}{}},
+	}
+	for _, test := range tests {
+		if value := humanizeValue(test.field, test.value, nil, config.TIME_LOCAL); !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("humanizeValue(%q, %v) = %v, expected %v", test.field, test.value, value, test.expected)
+		}
+	}
+}
+
+func TestHumanizeResponse(t *testing.T) {
+	response := map[string]interface{}{
+		"count":          float64(1),
+		"virtualmachine": []interface{}{map[string]interface{}{"name": "web-1", "memory": float64(1024)}, "plain"},
+		"capacity":       map[string]interface{}{"memorytotal": float64(1 << 20)},
+	}
+	expected := map[string]interface{}{
+		"count":          float64(1),
+		"virtualmachine": []interface{}{map[string]interface{}{"name": "web-1", "memory": "1 GiB"}, "plain"},
+		"capacity":       map[string]interface{}{"memorytotal": "1 MiB"},
+	}
+	if result := humanizeResponse(response, nil, config.TIME_RELATIVE); !reflect.DeepEqual(result, expected) {
+		t.Errorf("humanizeResponse returned %v, expected %v", result, expected)
+	}
+	if response["virtualmachine"].([]interface{})[0].(map[string]interface{})["memory"] != float64(1024) {
+		t.Errorf("humanizeResponse changed the response it was given")
+	}
+}
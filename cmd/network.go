@@ -177,8 +177,37 @@ func pollAsyncJob(r *Request, jobID string) (map[string]interface{}, error) {
 	}
 }
 
+// addContextParams adds the active project, domain and account context to the
+// params if the API accepts them and they are not already provided
+func addContextParams(r *Request, api string, params url.Values) {
+	cachedAPI := r.Config.GetCache()[strings.ToLower(api)]
+	if cachedAPI == nil || r.Config.ActiveProfile == nil {
+		return
+	}
+	context := map[string]string{
+		"projectid": r.Config.ActiveProfile.ProjectID,
+		"domainid":  r.Config.ActiveProfile.DomainID,
+		"account":   r.Config.ActiveProfile.Account,
+	}
+	for _, key := range []string{"projectid", "domainid", "account"} {
+		value := context[key]
+		if len(value) == 0 || params.Has(key) || !cachedAPI.HasArg(key) {
+			continue
+		}
+		// CloudStack only accepts an account along with its domain, and not along with a project
+		if key == "account" && (!params.Has("domainid") || params.Has("projectid")) {
+			continue
+		}
+		config.Debug("Adding context param ", key, "=", value, " for API ", api)
+		params.Add(key, value)
+	}
+}
+
 // NewAPIRequest makes an API request to configured management server
 func NewAPIRequest(r *Request, api string, args []string, isAsync bool) (map[string]interface{}, error) {
+	if !r.Config.IsAPIAllowed(api) {
+		return nil, errors.New("API " + api + " is not allowed in the read-only profile " + r.Config.Core.ProfileName)
+	}
 	params := make(url.Values)
 	params.Add("command", api)
 	for _, arg := range args {
@@ -203,6 +232,7 @@ func NewAPIRequest(r *Request, api string, args []string, isAsync bool) (map[str
 			params.Add(key, value)
 		}
 	}
+	addContextParams(r, api, params)
 	params.Add("response", "json")
 
 	var encodedParams string
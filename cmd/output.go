@@ -21,31 +21,185 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"os"
+	"html"
+	"io"
+	"math"
 	"reflect"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"text/template"
+	"time"
 
 	"github.com/apache/cloudstack-cloudmonkey/config"
 	"github.com/olekukonko/tablewriter"
 )
 
+// printer prints a result in the key order, colours and width of its output options
+type printer struct {
+	// filter holds the filter= keys of the printed result, which take precedence over fieldOrder
+	filter []string
+	// fieldOrder holds the well-known fields that are printed first, in order
+	fieldOrder []string
+	// theme is the colour theme of the printed result
+	theme *colorTheme
+	// width is the terminal width the table and column output is fitted to, 0 to not fit it
+	width int
+	// wrap holds the columns whose cells are wrapped instead of truncated
+	wrap []string
+}
+
+// defaultPrinter orders the keys by the default field order and prints without colours
+var defaultPrinter = &printer{fieldOrder: strings.Split(config.DefaultFieldOrder, ","), theme: noColors}
+
+// newPrinter returns the printer of a result with the output options and filter keys
+func newPrinter(filter []string, options outputOptions) *printer {
+	p := &printer{
+		filter:     filter,
+		fieldOrder: defaultPrinter.fieldOrder,
+		theme:      noColors,
+		width:      options.Width,
+		wrap:       options.Wrap,
+	}
+	if len(options.FieldOrder) > 0 {
+		p.fieldOrder = options.FieldOrder
+	}
+	if options.Theme != nil {
+		p.theme = options.Theme
+	}
+	return p
+}
+
+func indexOf(items []string, item string) int {
+	for idx, value := range items {
+		if value == item {
+			return idx
+		}
+	}
+	return -1
+}
+
+// orderedKeys returns the keys of a map in the default output order
+func orderedKeys(value map[string]interface{}) []string {
+	return defaultPrinter.orderedKeys(value)
+}
+
+// orderedKeys returns the keys of a map in output order, filter keys first, then
+// the well-known fields and then the remaining keys alphabetically
+func (p *printer) orderedKeys(value map[string]interface{}) []string {
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	rank := func(key string) (int, int) {
+		if idx := indexOf(p.filter, key); idx >= 0 {
+			return 0, idx
+		}
+		if idx := indexOf(p.fieldOrder, key); idx >= 0 {
+			return 1, idx
+		}
+		return 2, 0
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		groupI, idxI := rank(keys[i])
+		groupJ, idxJ := rank(keys[j])
+		if groupI != groupJ {
+			return groupI < groupJ
+		}
+		if idxI != idxJ {
+			return idxI < idxJ
+		}
+		if cmp := naturalCompare(keys[i], keys[j]); cmp != 0 {
+			return cmp < 0
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func (p *printer) writeJSON(out *strings.Builder, value interface{}, pretty bool, indent string, level int, theme *colorTheme) {
+	newline := func(level int) {
+		if pretty {
+			out.WriteString("\n" + strings.Repeat(indent, level))
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			out.WriteString("{}")
+			return
+		}
+		out.WriteString("{")
+		for idx, key := range p.orderedKeys(v) {
+			if idx > 0 {
+				out.WriteString(",")
+			}
+			newline(level + 1)
+			out.WriteString(paint(theme.Key, encodeJSONScalar(key)))
+			out.WriteString(":")
+			if pretty {
+				out.WriteString(" ")
+			}
+			p.writeJSON(out, v[key], pretty, indent, level+1, theme)
+		}
+		newline(level)
+		out.WriteString("}")
+	case []interface{}:
+		if len(v) == 0 {
+			out.WriteString("[]")
+			return
+		}
+		out.WriteString("[")
+		for idx, item := range v {
+			if idx > 0 {
+				out.WriteString(",")
+			}
+			newline(level + 1)
+			p.writeJSON(out, item, pretty, indent, level+1, theme)
+		}
+		newline(level)
+		out.WriteString("]")
+	case string:
+		out.WriteString(paint(theme.String, encodeJSONScalar(v)))
+	case float64, json.Number:
+		out.WriteString(paint(theme.Number, encodeJSONScalar(v)))
+	default:
+		out.WriteString(paint(theme.Literal, encodeJSONScalar(v)))
+	}
+}
+
+func encodeJSONScalar(value interface{}) string {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// marshalJSON encodes a value as JSON with its keys in the default output order
+func marshalJSON(value interface{}, pretty bool, indent string) string {
+	return defaultPrinter.marshalJSON(value, pretty, indent)
+}
+
+// marshalJSON encodes a value as JSON with its keys in output order
+func (p *printer) marshalJSON(value interface{}, pretty bool, indent string) string {
+	var out strings.Builder
+	p.writeJSON(&out, value, pretty, indent, 0, noColors)
+	return out.String()
+}
+
 func jsonify(value interface{}, format string) string {
+	return defaultPrinter.jsonify(value, format)
+}
+
+func (p *printer) jsonify(value interface{}, format string) string {
 	if value == nil {
 		return ""
 	}
 	if reflect.TypeOf(value).Kind() == reflect.Map || reflect.TypeOf(value).Kind() == reflect.Slice {
-		var jsonStr []byte
-		var err error
-		if format == "text" {
-			jsonStr, err = json.MarshalIndent(value, "", "")
-		} else {
-			jsonStr, err = json.Marshal(value)
-		}
-		if err == nil {
-			value = string(jsonStr)
-		}
+		value = p.marshalJSON(value, format == "text", "")
 	}
 	switch value.(type) {
 	case float64, float32:
@@ -55,165 +209,925 @@ func jsonify(value interface{}, format string) string {
 	}
 }
 
-func printJSON(response map[string]interface{}) {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	enc.Encode(response)
+func (p *printer) printJSON(response map[string]interface{}) {
+	var out strings.Builder
+	p.writeJSON(&out, response, true, "  ", 0, p.theme)
+	fmt.Fprintln(outputWriter, out.String())
+}
+
+// printNDJSON prints every list item as a compact JSON object on its own line,
+// optionally wrapped in an object with the response key such as virtualmachine
+func (p *printer) printNDJSON(response map[string]interface{}, withKey bool) {
+	hasList := false
+	for _, key := range p.orderedKeys(response) {
+		items, ok := response[key].([]interface{})
+		if !ok {
+			continue
+		}
+		hasList = true
+		for _, item := range items {
+			if withKey {
+				item = map[string]interface{}{key: item}
+			}
+			fmt.Fprintln(outputWriter, p.marshalJSON(item, false, ""))
+		}
+	}
+	if !hasList {
+		fmt.Fprintln(outputWriter, p.marshalJSON(response, false, ""))
+	}
 }
 
-func printText(response map[string]interface{}) {
+func (p *printer) printText(response map[string]interface{}) {
 	format := "text"
-	for k, v := range response {
+	for _, k := range p.orderedKeys(response) {
+		v := response[k]
 		valueType := reflect.TypeOf(v)
 		if valueType.Kind() == reflect.Slice {
-			fmt.Printf("%v:\n", k)
+			fmt.Fprintf(outputWriter, "%v:\n", k)
 			for idx, item := range v.([]interface{}) {
 				if idx > 0 {
-					fmt.Println("================================================================================")
+					fmt.Fprintln(outputWriter, "================================================================================")
 				}
 				row, isMap := item.(map[string]interface{})
 				if isMap {
-					for field, value := range row {
-						fmt.Printf("%s = %v\n", field, jsonify(value, format))
+					for _, field := range p.orderedKeys(row) {
+						fmt.Fprintf(outputWriter, "%s = %v\n", field, p.colorField(field, p.jsonify(row[field], format)))
 					}
 				} else {
-					fmt.Printf("%v\n", item)
+					fmt.Fprintf(outputWriter, "%v\n", item)
 				}
 			}
 		} else {
-			fmt.Printf("%v = %v\n", k, jsonify(v, format))
+			fmt.Fprintf(outputWriter, "%v = %v\n", k, p.colorField(k, p.jsonify(v, format)))
 		}
 	}
 }
 
-func printTable(response map[string]interface{}, filter []string) {
+func (p *printer) printTable(response map[string]interface{}, filter []string) {
 	format := "table"
-	table := tablewriter.NewWriter(os.Stdout)
-	for k, v := range response {
+	table := tablewriter.NewWriter(outputWriter)
+	table.SetAutoFormatHeaders(false)
+	if p.width > 0 {
+		table.SetAutoWrapText(false)
+	}
+	var dropped []string
+	for _, k := range p.orderedKeys(response) {
+		v := response[k]
 		valueType := reflect.TypeOf(v)
 		if valueType.Kind() == reflect.Slice {
 			items, ok := v.([]interface{})
 			if !ok {
 				continue
 			}
-			fmt.Printf("%v:\n", k)
-			var header []string
+			fmt.Fprintf(outputWriter, "%v:\n", k)
+			header := p.tableHeader(items, filter)
+			var rows [][]string
 			for _, item := range items {
 				row, ok := item.(map[string]interface{})
 				if !ok || len(row) < 1 {
 					continue
 				}
-				if len(header) == 0 {
-					if len(filter) > 0 {
-						header = filter
-					} else {
-						for field := range row {
-							header = append(header, field)
-						}
-						sort.Strings(header)
-					}
-					table.SetHeader(header)
-				}
 				var rowArray []string
 				for _, field := range header {
-					rowArray = append(rowArray, jsonify(row[field], format))
+					rowArray = append(rowArray, p.jsonify(row[field], format))
+				}
+				rows = append(rows, rowArray)
+			}
+			if len(rows) == 0 {
+				continue
+			}
+			layout := p.layoutColumns(header, rows, func(columns int) int {
+				return 3*columns + 1
+			})
+			var title []string
+			for idx, field := range layout.header {
+				title = append(title, layout.title(idx, strings.ToUpper(field)))
+			}
+			table.SetHeader(title)
+			for _, row := range rows {
+				var rowArray []string
+				for _, lines := range p.cells(layout, row) {
+					rowArray = append(rowArray, strings.Join(lines, "\n"))
 				}
 				table.Append(rowArray)
 			}
+			dropped = append(dropped, layout.dropped(header)...)
 		} else {
-			fmt.Printf("%v = %v\n", k, v)
+			fmt.Fprintf(outputWriter, "%v = %v\n", k, v)
 		}
 	}
 	table.Render()
+	printDroppedColumns(dropped)
 }
 
-func printColumn(response map[string]interface{}, filter []string) {
-	format := "column"
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.DiscardEmptyColumns)
-	for _, v := range response {
-		valueType := reflect.TypeOf(v)
-		if valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Map {
-			items, ok := v.([]interface{})
-			if !ok {
+// tableHeader returns the filter keys or the ordered keys of the first list item
+func (p *printer) tableHeader(items []interface{}, filter []string) []string {
+	if len(filter) > 0 {
+		return filter
+	}
+	for _, item := range items {
+		if row, ok := item.(map[string]interface{}); ok && len(row) > 0 {
+			return p.orderedKeys(row)
+		}
+	}
+	return nil
+}
+
+func escapeMarkdown(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\r\n", "<br>")
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
+
+func (p *printer) printMarkdown(response map[string]interface{}, filter []string) {
+	format := "markdown"
+	for _, k := range p.orderedKeys(response) {
+		items, ok := response[k].([]interface{})
+		if !ok {
+			fmt.Fprintf(outputWriter, "%v = %v\n\n", k, escapeMarkdown(p.jsonify(response[k], format)))
+			continue
+		}
+		header := p.tableHeader(items, filter)
+		if len(header) == 0 {
+			continue
+		}
+		fmt.Fprintf(outputWriter, "%v:\n\n", k)
+		var cells []string
+		for _, field := range header {
+			cells = append(cells, escapeMarkdown(field))
+		}
+		fmt.Fprintf(outputWriter, "| %s |\n", strings.Join(cells, " | "))
+		fmt.Fprintf(outputWriter, "|%s\n", strings.Repeat(" --- |", len(header)))
+		for _, item := range items {
+			row, ok := item.(map[string]interface{})
+			if !ok || len(row) < 1 {
 				continue
 			}
-			var header []string
-			for idx, item := range items {
-				row, ok := item.(map[string]interface{})
-				if !ok || len(row) < 1 {
-					continue
-				}
+			cells = nil
+			for _, field := range header {
+				cells = append(cells, escapeMarkdown(p.jsonify(row[field], format)))
+			}
+			fmt.Fprintf(outputWriter, "| %s |\n", strings.Join(cells, " | "))
+		}
+		fmt.Fprintln(outputWriter)
+	}
+}
+
+func (p *printer) printHTML(response map[string]interface{}, filter []string) {
+	format := "html"
+	for _, k := range p.orderedKeys(response) {
+		items, ok := response[k].([]interface{})
+		if !ok {
+			fmt.Fprintf(outputWriter, "<p>%s = %s</p>\n", html.EscapeString(k), html.EscapeString(p.jsonify(response[k], format)))
+			continue
+		}
+		header := p.tableHeader(items, filter)
+		if len(header) == 0 {
+			continue
+		}
+		fmt.Fprintln(outputWriter, "<table>")
+		fmt.Fprintf(outputWriter, "<caption>%s</caption>\n", html.EscapeString(k))
+		fmt.Fprint(outputWriter, "<thead><tr>")
+		for _, field := range header {
+			fmt.Fprintf(outputWriter, "<th>%s</th>", html.EscapeString(field))
+		}
+		fmt.Fprintln(outputWriter, "</tr></thead>")
+		fmt.Fprintln(outputWriter, "<tbody>")
+		for _, item := range items {
+			row, ok := item.(map[string]interface{})
+			if !ok || len(row) < 1 {
+				continue
+			}
+			fmt.Fprint(outputWriter, "<tr>")
+			for _, field := range header {
+				fmt.Fprintf(outputWriter, "<td>%s</td>", html.EscapeString(p.jsonify(row[field], format)))
+			}
+			fmt.Fprintln(outputWriter, "</tr>")
+		}
+		fmt.Fprintln(outputWriter, "</tbody>")
+		fmt.Fprintln(outputWriter, "</table>")
+	}
+}
+
+// isObjectResponse returns true for a response that is a plain object rather than
+// lists or a single object under its noun, such as the result of a query
+func isObjectResponse(response map[string]interface{}) bool {
+	for _, value := range response {
+		switch value.(type) {
+		case []interface{}:
+			return false
+		case map[string]interface{}:
+			if len(response) == 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// flattenRow flattens the nested objects and lists of a row into dotted columns such
+// as details.key or nic.0.ipaddress, lists of plain values are joined
+func flattenRow(prefix string, value interface{}, row map[string]interface{}) {
+	column := func(key string) string {
+		if len(prefix) == 0 {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flattenRow(column(key), item, row)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return
+		}
+		if flattened, ok := flattenValue(v).(string); ok {
+			row[prefix] = flattened
+			return
+		}
+		for idx, item := range v {
+			flattenRow(column(strconv.Itoa(idx)), item, row)
+		}
+	default:
+		row[prefix] = value
+	}
+}
+
+// tabularRows returns the flattened rows of a list or a single object and their
+// columns, a filter key of a nested object selects all its flattened columns
+func (p *printer) tabularRows(value interface{}, filter []string) ([]map[string]interface{}, []string) {
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case map[string]interface{}:
+		items = []interface{}{v}
+	default:
+		return nil, nil
+	}
+	var rows []map[string]interface{}
+	columns := make(map[string]interface{})
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok || len(object) < 1 {
+			continue
+		}
+		row := make(map[string]interface{})
+		flattenRow("", object, row)
+		for column := range row {
+			columns[column] = nil
+		}
+		rows = append(rows, row)
+	}
+	if len(filter) == 0 {
+		return rows, p.orderedKeys(columns)
+	}
+	var header []string
+	for _, key := range filter {
+		if _, ok := columns[key]; ok {
+			header = append(header, key)
+			continue
+		}
+		var nested []string
+		for column := range columns {
+			if strings.HasPrefix(column, key+".") {
+				nested = append(nested, column)
+			}
+		}
+		if len(nested) == 0 {
+			header = append(header, key)
+			continue
+		}
+		sort.Slice(nested, func(i, j int) bool {
+			return naturalCompare(nested[i], nested[j]) < 0
+		})
+		header = append(header, nested...)
+	}
+	return rows, header
+}
 
-				if idx == 0 {
-					if len(filter) > 0 {
-						header = filter
-					} else {
-						for rk := range row {
-							header = append(header, strings.ToUpper(rk))
+// explodeResponse repeats a list item for every element of its explode= lists,
+// so that a nested list such as nic is printed as one row per element
+func explodeResponse(response map[string]interface{}, fields []string) map[string]interface{} {
+	if len(fields) == 0 {
+		return response
+	}
+	explodedResponse := make(map[string]interface{})
+	for key, value := range response {
+		items, ok := value.([]interface{})
+		if !ok {
+			if object, isObject := value.(map[string]interface{}); isObject {
+				items = []interface{}{object}
+			} else {
+				explodedResponse[key] = value
+				continue
+			}
+		}
+		rows := []interface{}{}
+		for _, item := range items {
+			exploded := []interface{}{item}
+			for _, field := range fields {
+				var next []interface{}
+				for _, explodedItem := range exploded {
+					row, ok := explodedItem.(map[string]interface{})
+					nested, isList := row[field].([]interface{})
+					if !ok || !isList || len(nested) == 0 {
+						next = append(next, explodedItem)
+						continue
+					}
+					for _, element := range nested {
+						copied := make(map[string]interface{}, len(row))
+						for rowKey, rowValue := range row {
+							copied[rowKey] = rowValue
 						}
-						sort.Strings(header)
+						copied[field] = element
+						next = append(next, copied)
 					}
-					fmt.Fprintln(w, strings.Join(header, "\t"))
 				}
-				var values []string
-				for _, key := range header {
-					values = append(values, jsonify(row[strings.ToLower(key)], format))
+				exploded = next
+			}
+			rows = append(rows, exploded...)
+		}
+		explodedResponse[key] = rows
+	}
+	return explodedResponse
+}
+
+// writeColumnRows writes the rows of a column output, the lines of wrapped cells
+// are continued on the following lines
+func (p *printer) writeColumnRows(w io.Writer, layout columnLayout, rows [][]string) {
+	for _, row := range rows {
+		cells := p.cells(layout, row)
+		height := 1
+		for _, lines := range cells {
+			if len(lines) > height {
+				height = len(lines)
+			}
+		}
+		for line := 0; line < height; line++ {
+			var values []string
+			for idx, lines := range cells {
+				value := p.colorFieldAs(layout.header[idx], row[idx], "")
+				if line < len(lines) {
+					value = lines[line]
 				}
-				fmt.Fprintln(w, strings.Join(values, "\t"))
+				values = append(values, value)
 			}
+			fmt.Fprintln(w, strings.Join(values, "\t"))
 		}
 	}
+}
+
+// printColumn prints the lists of a response as aligned columns, the headers are upper
+// case unless the columns are the filter= keys
+func (p *printer) printColumn(response map[string]interface{}, filter []string, upperCaseHeader bool) {
+	format := "column"
+	w := tabwriter.NewWriter(outputWriter, 0, 0, 3, ' ', tabwriter.DiscardEmptyColumns)
+	overhead := func(columns int) int {
+		return 3 * (columns - 1)
+	}
+	if isObjectResponse(response) {
+		rows, header := p.tabularRows(response, filter)
+		var values [][]string
+		for _, row := range rows {
+			for _, key := range header {
+				values = append(values, []string{key, p.jsonify(row[key], format)})
+			}
+		}
+		layout := p.layoutColumns([]string{"KEY", "VALUE"}, values, overhead)
+		fmt.Fprintln(w, strings.Join(layout.header, "\t"))
+		for _, value := range values {
+			if len(layout.wrap) > 1 {
+				layout.wrap[1] = indexOf(p.wrap, value[0]) >= 0
+			}
+			for idx, line := range layout.lines(1, value[1]) {
+				key := value[0]
+				if idx > 0 {
+					key = ""
+				}
+				fmt.Fprintf(w, "%s\t%s\n", key, p.colorFieldAs(value[0], value[1], line))
+			}
+		}
+		w.Flush()
+		return
+	}
+	var dropped []string
+	for _, k := range p.orderedKeys(response) {
+		rows, header := p.tabularRows(response[k], filter)
+		if len(rows) == 0 {
+			continue
+		}
+		var values [][]string
+		for _, row := range rows {
+			var rowValues []string
+			for _, key := range header {
+				rowValues = append(rowValues, p.jsonify(row[key], format))
+			}
+			values = append(values, rowValues)
+		}
+		layout := p.layoutColumns(header, values, overhead)
+		var title []string
+		for idx, key := range layout.header {
+			if upperCaseHeader {
+				key = strings.ToUpper(key)
+			}
+			key = layout.title(idx, key)
+			if stateFields[strings.ToLower(layout.header[idx])] {
+				key = paint(p.theme.Neutral, key)
+			}
+			title = append(title, key)
+		}
+		fmt.Fprintln(w, strings.Join(title, "\t"))
+		p.writeColumnRows(w, layout, values)
+		dropped = append(dropped, layout.dropped(header)...)
+	}
 	w.Flush()
+	printDroppedColumns(dropped)
 }
 
-func printCsv(response map[string]interface{}, filter []string) {
+func (p *printer) printCsv(response map[string]interface{}, filter []string) {
 	format := "csv"
-	enc := csv.NewWriter(os.Stdout)
-	for _, v := range response {
-		valueType := reflect.TypeOf(v)
-		if valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Map {
-			items, ok := v.([]interface{})
-			if !ok {
-				continue
+	enc := csv.NewWriter(outputWriter)
+	if isObjectResponse(response) {
+		rows, header := p.tabularRows(response, filter)
+		enc.Write([]string{"key", "value"})
+		for _, row := range rows {
+			for _, key := range header {
+				enc.Write([]string{key, p.jsonify(row[key], format)})
 			}
-			var header []string
-			for idx, item := range items {
-				row, ok := item.(map[string]interface{})
-				if !ok || len(row) < 1 {
-					continue
-				}
+		}
+		enc.Flush()
+		return
+	}
+	for _, k := range p.orderedKeys(response) {
+		rows, header := p.tabularRows(response[k], filter)
+		if len(rows) == 0 {
+			continue
+		}
+		enc.Write(header)
+		for _, row := range rows {
+			var values []string
+			for _, key := range header {
+				values = append(values, p.jsonify(row[key], format))
+			}
+			enc.Write(values)
+		}
+	}
+	enc.Flush()
+}
 
-				if idx == 0 {
-					if len(filter) > 0 {
-						header = filter
-					} else {
-						for rk := range row {
-							header = append(header, rk)
-						}
-						sort.Strings(header)
-					}
-					enc.Write(header)
+var yamlPlainRegex = regexp.MustCompile(`^[a-zA-Z0-9_/][a-zA-Z0-9_ ./()+=-]*$`)
+var yamlAmbiguousRegex = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}|0[xXoObB])`)
+
+func yamlString(value string) string {
+	switch strings.ToLower(value) {
+	case "", "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(value)
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil || !yamlPlainRegex.MatchString(value) ||
+		yamlAmbiguousRegex.MatchString(value) || strings.HasSuffix(value, " ") {
+		quoted, _ := json.Marshal(value)
+		return string(quoted)
+	}
+	return value
+}
+
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return yamlString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "{}"
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]"
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func isYAMLScalar(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return true
+}
+
+func (p *printer) writeYAMLMap(out *strings.Builder, value map[string]interface{}, indent int, firstIndented bool) {
+	for idx, key := range p.orderedKeys(value) {
+		if idx > 0 || !firstIndented {
+			out.WriteString(strings.Repeat(" ", indent))
+		}
+		out.WriteString(yamlString(key) + ":")
+		p.writeYAMLValue(out, value[key], indent+2)
+	}
+}
+
+func (p *printer) writeYAMLValue(out *strings.Builder, value interface{}, indent int) {
+	if isYAMLScalar(value) {
+		out.WriteString(" " + yamlScalar(value) + "\n")
+		return
+	}
+	out.WriteString("\n")
+	switch v := value.(type) {
+	case map[string]interface{}:
+		p.writeYAMLMap(out, v, indent, false)
+	case []interface{}:
+		for _, item := range v {
+			out.WriteString(strings.Repeat(" ", indent) + "-")
+			if row, ok := item.(map[string]interface{}); ok && len(row) > 0 {
+				out.WriteString(" ")
+				p.writeYAMLMap(out, row, indent+2, true)
+			} else {
+				p.writeYAMLValue(out, item, indent+2)
+			}
+		}
+	}
+}
+
+func (p *printer) printYAML(response map[string]interface{}) {
+	var out strings.Builder
+	p.writeYAMLMap(&out, response, 0, false)
+	fmt.Fprint(outputWriter, out.String())
+}
+
+// flattenValue joins a list of plain values selected by a filter path into a single cell
+func flattenValue(value interface{}) interface{} {
+	items, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	var values []string
+	for _, item := range items {
+		if reflect.TypeOf(item) != nil && (reflect.TypeOf(item).Kind() == reflect.Map || reflect.TypeOf(item).Kind() == reflect.Slice) {
+			return value
+		}
+		values = append(values, jsonify(item, ""))
+	}
+	return strings.Join(values, ",")
+}
+
+var sortDateLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseSortDate(value string) (time.Time, bool) {
+	for _, layout := range sortDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// naturalCompare compares strings with embedded numbers by their numeric value, so web-2 sorts before web-10
+func naturalCompare(a, b string) int {
+	for len(a) > 0 && len(b) > 0 {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i, j := 0, 0
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA := strings.TrimLeft(a[:i], "0")
+			numB := strings.TrimLeft(b[:j], "0")
+			if len(numA) != len(numB) {
+				return len(numA) - len(numB)
+			}
+			if cmp := strings.Compare(numA, numB); cmp != 0 {
+				return cmp
+			}
+			a, b = a[i:], b[j:]
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// compareSortValues compares two field values numerically, as dates or as strings, missing values sort last
+func compareSortValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return 1
+		}
+		return -1
+	}
+	numA, okA := toNumber(a)
+	numB, okB := toNumber(b)
+	if okA && okB {
+		switch {
+		case numA < numB:
+			return -1
+		case numA > numB:
+			return 1
+		}
+		return 0
+	}
+	strA, strB := jsonify(a, ""), jsonify(b, "")
+	dateA, okA := parseSortDate(strA)
+	dateB, okB := parseSortDate(strB)
+	if okA && okB {
+		return dateA.Compare(dateB)
+	}
+	return naturalCompare(strings.ToLower(strA), strings.ToLower(strB))
+}
+
+type sortKey struct {
+	path       queryNode
+	descending bool
+}
+
+func parseSortKeys(sortBy []string) []sortKey {
+	var keys []sortKey
+	for _, field := range sortBy {
+		descending := false
+		if strings.HasPrefix(field, "-") {
+			descending = true
+			field = field[1:]
+		}
+		if idx := strings.LastIndex(field, ":"); idx > 0 {
+			switch strings.ToLower(field[idx+1:]) {
+			case "desc":
+				descending = true
+				field = field[:idx]
+			case "asc":
+				field = field[:idx]
+			}
+		}
+		path, err := compileQuery(field)
+		if err != nil {
+			config.Debug("Invalid sortby field ", field, ": ", err)
+			continue
+		}
+		keys = append(keys, sortKey{path: path, descending: descending})
+	}
+	return keys
+}
+
+// sortResponse sorts the list items of a response by one or more fields
+func sortResponse(response map[string]interface{}, sortBy []string) map[string]interface{} {
+	keys := parseSortKeys(sortBy)
+	if len(keys) == 0 {
+		return response
+	}
+	for k, v := range response {
+		items, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		sorted := append([]interface{}{}, items...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			for _, key := range keys {
+				a, b := key.path(sorted[i]), key.path(sorted[j])
+				cmp := compareSortValues(a, b)
+				if cmp == 0 {
+					continue
 				}
-				var values []string
-				for _, key := range header {
-					values = append(values, jsonify(row[key], format))
+				if key.descending && a != nil && b != nil {
+					cmp = -cmp
 				}
-				enc.Write(values)
+				return cmp < 0
+			}
+			return false
+		})
+		response[k] = sorted
+	}
+	return response
+}
+
+var aggregateRegex = regexp.MustCompile(`^(count|sum|avg|min|max)(?:\(([^()]+)\))?$`)
+
+// aggregate is an agg= function such as count or sum(memory) computed per group
+type aggregate struct {
+	name     string
+	function string
+	path     queryNode
+}
+
+// parseAggregates parses the agg= functions, count is used when none are given
+func parseAggregates(value string) ([]aggregate, error) {
+	names := splitFakeArgList(value)
+	if len(names) == 0 {
+		names = []string{"count"}
+	}
+	var aggregates []aggregate
+	for _, name := range names {
+		match := aggregateRegex.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("invalid aggregate %s, supported are count, sum(<field>), avg(<field>), min(<field>) and max(<field>)", name)
+		}
+		agg := aggregate{name: name, function: match[1]}
+		if len(match[2]) > 0 {
+			path, err := compileQuery(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid aggregate field %s: %v", match[2], err)
 			}
+			agg.path = path
+		} else if agg.function != "count" {
+			return nil, fmt.Errorf("aggregate %s requires a field, such as %s(memory)", name, name)
 		}
+		aggregates = append(aggregates, agg)
 	}
-	enc.Flush()
+	return aggregates, nil
+}
+
+func (agg aggregate) compute(rows []map[string]interface{}) interface{} {
+	count := 0
+	var numbers []float64
+	for _, row := range rows {
+		if agg.path == nil {
+			count++
+			continue
+		}
+		value := agg.path(row)
+		if value == nil {
+			continue
+		}
+		count++
+		if number, ok := toNumber(value); ok {
+			numbers = append(numbers, number)
+		}
+	}
+	if agg.function == "count" {
+		return float64(count)
+	}
+	if len(numbers) == 0 {
+		return nil
+	}
+	result := numbers[0]
+	for _, number := range numbers[1:] {
+		switch agg.function {
+		case "sum", "avg":
+			result += number
+		case "min":
+			if number < result {
+				result = number
+			}
+		case "max":
+			if number > result {
+				result = number
+			}
+		}
+	}
+	if agg.function == "avg" {
+		result = math.Round(result/float64(len(numbers))*100) / 100
+	}
+	return aggregateNumber(result)
+}
+
+// aggregateNumber returns an aggregate result that keeps its decimals when printed,
+// the other numbers of a response are printed as integers
+func aggregateNumber(value float64) interface{} {
+	if value == math.Trunc(value) {
+		return value
+	}
+	return json.Number(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// aggregateResponse groups the list items by the groupby= fields, in order of
+// their first appearance, and replaces them with one summary row per group
+func aggregateResponse(response map[string]interface{}, groupBy []string, aggregates []aggregate) map[string]interface{} {
+	paths := make([]queryNode, len(groupBy))
+	for idx, field := range groupBy {
+		path, err := compileQuery(field)
+		if err != nil {
+			config.Debug("Invalid groupby field ", field, ": ", err)
+			path = func(interface{}) interface{} { return nil }
+		}
+		paths[idx] = path
+	}
+	aggregatedResponse := make(map[string]interface{})
+	count, hasList := 0, false
+	for key, value := range response {
+		items, ok := value.([]interface{})
+		if !ok {
+			aggregatedResponse[key] = value
+			continue
+		}
+		hasList = true
+		var order []string
+		groups := make(map[string][]map[string]interface{})
+		groupValues := make(map[string][]interface{})
+		for _, item := range items {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var values []interface{}
+			var keys []string
+			for _, path := range paths {
+				fieldValue := flattenValue(path(row))
+				values = append(values, fieldValue)
+				keys = append(keys, jsonify(fieldValue, ""))
+			}
+			groupKey := strings.Join(keys, "\x00")
+			if _, ok := groups[groupKey]; !ok {
+				order = append(order, groupKey)
+				groupValues[groupKey] = values
+			}
+			groups[groupKey] = append(groups[groupKey], row)
+		}
+		rows := []interface{}{}
+		for _, groupKey := range order {
+			summary := make(map[string]interface{})
+			for idx, field := range groupBy {
+				summary[field] = groupValues[groupKey][idx]
+			}
+			for _, agg := range aggregates {
+				summary[agg.name] = agg.compute(groups[groupKey])
+			}
+			rows = append(rows, summary)
+		}
+		count += len(rows)
+		aggregatedResponse[key] = rows
+	}
+	if _, ok := aggregatedResponse["count"]; ok && hasList {
+		aggregatedResponse["count"] = float64(count)
+	}
+	return aggregatedResponse
 }
 
 func filterResponse(response map[string]interface{}, filter []string, outputType string) map[string]interface{} {
 	if filter == nil || len(filter) == 0 {
 		return response
 	}
+	paths := make(map[string]queryNode)
+	for _, filterKey := range filter {
+		if strings.ContainsAny(filterKey, ".[") {
+			if path, err := compileQuery(filterKey); err == nil {
+				paths[filterKey] = path
+			} else {
+				config.Debug("Invalid filter path ", filterKey, ": ", err)
+			}
+		}
+	}
+	isTabular := outputType == config.COLUMN || outputType == config.CSV || outputType == config.TABLE ||
+		outputType == config.MARKDOWN || outputType == config.HTML
+	filterRow := func(row map[string]interface{}) map[string]interface{} {
+		filteredRow := make(map[string]interface{})
+		for _, filterKey := range filter {
+			if path, ok := paths[filterKey]; ok {
+				if value := path(row); value != nil {
+					if isTabular {
+						value = flattenValue(value)
+					}
+					filteredRow[filterKey] = value
+				}
+			}
+			for field := range row {
+				if filterKey == field {
+					filteredRow[field] = row[field]
+				}
+			}
+			if isTabular {
+				if _, ok := filteredRow[filterKey]; !ok {
+					filteredRow[filterKey] = ""
+				}
+			}
+		}
+		return filteredRow
+	}
+	if isObjectResponse(response) {
+		return filterRow(response)
+	}
 	filteredResponse := make(map[string]interface{})
 	for k, v := range response {
 		valueType := reflect.TypeOf(v)
 		if valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Map {
+			if object, ok := v.(map[string]interface{}); ok {
+				filteredResponse[k] = filterRow(object)
+				continue
+			}
 			items, ok := v.([]interface{})
 			if !ok {
 				continue
@@ -224,20 +1138,7 @@ func filterResponse(response map[string]interface{}, filter []string, outputType
 				if !ok || len(row) < 1 {
 					continue
 				}
-				filteredRow := make(map[string]interface{})
-				for _, filterKey := range filter {
-					for field := range row {
-						if filterKey == field {
-							filteredRow[field] = row[field]
-						}
-					}
-					if outputType == config.COLUMN || outputType == config.CSV || outputType == config.TABLE {
-						if _, ok := filteredRow[filterKey]; !ok {
-							filteredRow[filterKey] = ""
-						}
-					}
-				}
-				filteredRows = append(filteredRows, filteredRow)
+				filteredRows = append(filteredRows, filterRow(row))
 			}
 			filteredResponse[k] = filteredRows
 		} else {
@@ -249,21 +1150,100 @@ func filterResponse(response map[string]interface{}, filter []string, outputType
 	return filteredResponse
 }
 
-func printResult(outputType string, response map[string]interface{}, filter []string) {
+// outputOptions are the cloudmonkey specific args that shape the printed result
+type outputOptions struct {
+	Filter     []string
+	SortBy     []string
+	Template   *template.Template
+	Theme      *colorTheme
+	NDJSONKey  bool
+	FieldOrder []string
+	Where      wherePredicate
+	Explode    []string
+	GroupBy    []string
+	Aggregates []aggregate
+	Columns    func(noun string) []string
+	Humanize   bool
+	FieldTypes map[string]string
+	TimeFormat string
+	Width      int
+	Wrap       []string
+}
+
+// defaultColumns returns the configured columns of the first list in the response
+// that are present in at least one of its items
+func defaultColumns(response map[string]interface{}, columnsOf func(noun string) []string) []string {
+	for _, key := range orderedKeys(response) {
+		items, ok := response[key].([]interface{})
+		if !ok {
+			continue
+		}
+		var columns []string
+		for _, column := range columnsOf(key) {
+			path, err := compileQuery(column)
+			if err != nil {
+				config.Debug("Invalid column ", column, ": ", err)
+				continue
+			}
+			for _, item := range items {
+				if row, ok := item.(map[string]interface{}); ok && path(row) != nil {
+					columns = append(columns, column)
+					break
+				}
+			}
+		}
+		return columns
+	}
+	return nil
+}
+
+func printResult(outputType string, response map[string]interface{}, options outputOptions) {
+	filter := options.Filter
+	response = whereResponse(response, options.Where)
+	response = explodeResponse(response, options.Explode)
+	if len(options.Aggregates) > 0 {
+		response = aggregateResponse(response, options.GroupBy, options.Aggregates)
+		if len(filter) == 0 {
+			filter = append([]string{}, options.GroupBy...)
+			for _, agg := range options.Aggregates {
+				filter = append(filter, agg.name)
+			}
+		}
+	}
+	// the headers of the default columns are upper case like those of all fields
+	upperCaseHeader := len(filter) == 0
+	if len(filter) == 0 && options.Columns != nil && (outputType == config.TABLE || outputType == config.COLUMN) {
+		filter = defaultColumns(response, options.Columns)
+	}
+	p := newPrinter(filter, options)
+	response = sortResponse(response, options.SortBy)
 	response = filterResponse(response, filter, outputType)
+	if options.Humanize && (outputType == config.TABLE || outputType == config.COLUMN || outputType == config.TEXT) {
+		response = humanizeResponse(response, options.FieldTypes, options.TimeFormat)
+	}
 	switch outputType {
 	case config.JSON:
-		printJSON(response)
+		p.printJSON(response)
 	case config.TEXT:
-		printText(response)
+		p.printText(response)
 	case config.COLUMN:
-		printColumn(response, filter)
+		p.printColumn(response, filter, upperCaseHeader)
 	case config.CSV:
-		printCsv(response, filter)
+		p.printCsv(response, filter)
 	case config.TABLE:
-		printTable(response, filter)
+		p.printTable(response, filter)
+	case config.YAML:
+		p.printYAML(response)
+	case config.TEMPLATE:
+		p.printTemplate(response, options.Template)
+	case config.MARKDOWN:
+		p.printMarkdown(response, filter)
+	case config.HTML:
+		p.printHTML(response, filter)
+	case config.NDJSON:
+		p.printNDJSON(response, options.NDJSONKey)
 	case config.DEFAULT:
-		printJSON(response)
+		p.printJSON(response)
 	default:
 		fmt.Println("Invalid output type configured, please fix that!")
 	}
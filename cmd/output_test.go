@@ -0,0 +1,399 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+func TestPrinterOrderedKeys(t *testing.T) {
+	row := map[string]interface{}{"zonename": "zone1", "state": "Running", "name": "web-1", "id": "1", "memory": 2048}
+	tests := []struct {
+		printer  *printer
+		expected []string
+	}{
+		{defaultPrinter, []string{"id", "name", "state", "zonename", "memory"}},
+		{newPrinter([]string{"state", "memory"}, outputOptions{}), []string{"state", "memory", "id", "name", "zonename"}},
+		{newPrinter(nil, outputOptions{FieldOrder: []string{"zonename", "name"}}), []string{"zonename", "name", "id", "memory", "state"}},
+		{newPrinter(nil, outputOptions{}), []string{"id", "name", "state", "zonename", "memory"}},
+	}
+	for _, test := range tests {
+		if keys := test.printer.orderedKeys(row); !reflect.DeepEqual(keys, test.expected) {
+			t.Errorf("orderedKeys with filter %v and field order %v returned %v, expected %v", test.printer.filter, test.printer.fieldOrder, keys, test.expected)
+		}
+	}
+	if keys := orderedKeys(row); !reflect.DeepEqual(keys, tests[0].expected) {
+		t.Errorf("orderedKeys returned %v after printing with other options, expected %v", keys, tests[0].expected)
+	}
+}
+
+func TestPrintColumnHeader(t *testing.T) {
+	response := map[string]interface{}{
+		"count":          float64(1),
+		"virtualmachine": []interface{}{map[string]interface{}{"id": "1", "name": "web-1", "state": "Running"}},
+	}
+	columns := func(noun string) []string {
+		return []string{"name", "state"}
+	}
+	tests := []struct {
+		options  outputOptions
+		expected string
+	}{
+		{outputOptions{}, "ID   NAME    STATE"},
+		{outputOptions{Columns: columns}, "NAME    STATE"},
+		{outputOptions{Filter: []string{"name", "state"}, Columns: columns}, "name    state"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		previous := SetOutputWriter(&out)
+		printResult(config.COLUMN, response, test.options)
+		SetOutputWriter(previous)
+		if header := strings.SplitN(out.String(), "\n", 2)[0]; header != test.expected {
+			t.Errorf("printColumn with filter %v printed header %q, expected %q", test.options.Filter, header, test.expected)
+		}
+	}
+}
+
+func TestAggregateResponse(t *testing.T) {
+	response := map[string]interface{}{
+		"count": float64(3),
+		"virtualmachine": []interface{}{
+			map[string]interface{}{"zonename": "zone1", "cpunumber": float64(1), "memory": float64(1024)},
+			map[string]interface{}{"zonename": "zone1", "cpunumber": float64(2), "memory": float64(2048)},
+			map[string]interface{}{"zonename": "zone2", "cpunumber": float64(4)},
+		},
+	}
+	aggregates, err := parseAggregates("count,sum(cpunumber),avg(cpunumber),max(memory)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := aggregateResponse(response, []string{"zonename"}, aggregates)
+	expected := `{"count":2,"virtualmachine":[` +
+		`{"zonename":"zone1","count":2,"sum(cpunumber)":3,"avg(cpunumber)":1.5,"max(memory)":2048},` +
+		`{"zonename":"zone2","count":1,"sum(cpunumber)":4,"avg(cpunumber)":4,"max(memory)":null}]}`
+	p := newPrinter([]string{"zonename", "count", "sum(cpunumber)", "avg(cpunumber)", "max(memory)"}, outputOptions{})
+	if output := p.marshalJSON(result, false, ""); output != expected {
+		t.Errorf("aggregateResponse returned %s, expected %s", output, expected)
+	}
+
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{aggregateNumber(1.5), "1.5"},
+		{aggregateNumber(2), "2"},
+		{float64(1.5), "2"},
+		{float64(2048), "2048"},
+	}
+	for _, test := range tests {
+		if value := jsonify(test.value, ""); value != test.expected {
+			t.Errorf("jsonify(%v) = %s, expected %s", test.value, value, test.expected)
+		}
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"web-1", "web-1"},
+		{"10.0.0.1", "10.0.0.1"},
+		{"Intel(R) Xeon 2.40GHz", "Intel(R) Xeon 2.40GHz"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"Yes", `"Yes"`},
+		{"off", `"off"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"42", `"42"`},
+		{"-1.5", `"-1.5"`},
+		{"1e3", `"1e3"`},
+		{"0x1F", `"0x1F"`},
+		{"0o17", `"0o17"`},
+		{"2024-01-02", `"2024-01-02"`},
+		{"2024-01-02T10:00:00+0000", `"2024-01-02T10:00:00+0000"`},
+		{"key: value", `"key: value"`},
+		{"- item", `"- item"`},
+		{"#comment", `"#comment"`},
+		{"*alias", `"*alias"`},
+		{"trailing ", `"trailing "`},
+		{"line 1\nline 2", `"line 1\nline 2"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"zoné", `"zoné"`},
+	}
+	for _, test := range tests {
+		if value := yamlString(test.value); value != test.expected {
+			t.Errorf("yamlString(%q) = %s, expected %s", test.value, value, test.expected)
+		}
+	}
+}
+
+func TestPrintYAML(t *testing.T) {
+	response := map[string]interface{}{
+		"count": float64(1),
+		"virtualmachine": []interface{}{
+			map[string]interface{}{
+				"id":       "1",
+				"name":     "web-1",
+				"memory":   float64(2048),
+				"ha":       false,
+				"details":  map[string]interface{}{"cpu.speed": "2000", "key": nil},
+				"nic":      []interface{}{map[string]interface{}{"ipaddress": "10.0.0.1"}},
+				"tags":     []interface{}{},
+				"affinity": map[string]interface{}{},
+				"ports":    []interface{}{float64(22), "80"},
+				"groups":   []interface{}{[]interface{}{"a"}},
+			},
+		},
+	}
+	expected := `count: 1
+virtualmachine:
+  - id: "1"
+    name: web-1
+    affinity: {}
+    details:
+      cpu.speed: "2000"
+      key: null
+    groups:
+      -
+        - a
+    ha: false
+    memory: 2048
+    nic:
+      - ipaddress: 10.0.0.1
+    ports:
+      - 22
+      - "80"
+    tags: []
+`
+	var out bytes.Buffer
+	previous := SetOutputWriter(&out)
+	defaultPrinter.printYAML(response)
+	SetOutputWriter(previous)
+	if out.String() != expected {
+		t.Errorf("printYAML printed\n%s\nexpected\n%s", out.String(), expected)
+	}
+}
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"web-2", "web-10", -1},
+		{"web-10", "web-2", 1},
+		{"web-02", "web-2", 0},
+		{"web-2a", "web-2b", -1},
+		{"v1.9.1", "v1.10.0", -1},
+		{"host", "host-1", -1},
+		{"10", "9", 1},
+		{"", "a", -1},
+		{"abc", "abc", 0},
+	}
+	sign := func(value int) int {
+		switch {
+		case value < 0:
+			return -1
+		case value > 0:
+			return 1
+		}
+		return 0
+	}
+	for _, test := range tests {
+		if cmp := sign(naturalCompare(test.a, test.b)); cmp != test.expected {
+			t.Errorf("naturalCompare(%q, %q) = %d, expected %d", test.a, test.b, cmp, test.expected)
+		}
+	}
+}
+
+func TestCompareSortValues(t *testing.T) {
+	tests := []struct {
+		a, b     interface{}
+		expected int
+	}{
+		{float64(9), float64(10), -1},
+		{"9", float64(10), -1},
+		{"10", "9", 1},
+		{"1.5", "1.25", 1},
+		{"2024-01-02T10:00:00+0000", "2023-12-31T23:00:00+0000", 1},
+		{"2024-01-02T10:00:00+0000", "2024-01-02T12:00:00+0200", 0},
+		{"2024-01-02", "2024-01-02 00:00:00", 0},
+		{"2024-01-02T10:00:00Z", "2024-03-01", -1},
+		{"2024-01-02", float64(5), 1},
+		{"Web-2", "web-10", -1},
+		{"zone1", nil, -1},
+		{nil, float64(1), 1},
+		{nil, nil, 0},
+		{true, false, 1},
+	}
+	for _, test := range tests {
+		cmp := compareSortValues(test.a, test.b)
+		if (cmp < 0 && test.expected >= 0) || (cmp > 0 && test.expected <= 0) || (cmp == 0 && test.expected != 0) {
+			t.Errorf("compareSortValues(%v, %v) = %d, expected %d", test.a, test.b, cmp, test.expected)
+		}
+	}
+}
+
+func TestSortResponse(t *testing.T) {
+	tests := []struct {
+		sortBy   []string
+		expected []string
+	}{
+		{[]string{"name"}, []string{"db-1", "web-2", "web-10", "web-11"}},
+		{[]string{"-name"}, []string{"web-11", "web-10", "web-2", "db-1"}},
+		{[]string{"memory", "name:desc"}, []string{"web-10", "web-2", "db-1", "web-11"}},
+		{[]string{"created:asc"}, []string{"web-11", "db-1", "web-2", "web-10"}},
+		{[]string{"-created"}, []string{"web-10", "web-2", "db-1", "web-11"}},
+		{[]string{"nic[0].ipaddress"}, []string{"web-10", "web-2", "db-1", "web-11"}},
+		{[]string{"nic[0"}, []string{"web-2", "db-1", "web-11", "web-10"}},
+	}
+	for _, test := range tests {
+		response := map[string]interface{}{
+			"count": float64(4),
+			"virtualmachine": []interface{}{
+				map[string]interface{}{"name": "web-2", "memory": float64(2048), "created": "2024-01-02T10:00:00+0000", "nic": []interface{}{map[string]interface{}{"ipaddress": "10.0.0.9"}}},
+				map[string]interface{}{"name": "db-1", "memory": "4096", "created": "2023-12-01T10:00:00+0000", "nic": []interface{}{map[string]interface{}{"ipaddress": "10.0.0.10"}}},
+				map[string]interface{}{"name": "web-11", "created": "2023-06-01 10:00:00", "nic": []interface{}{map[string]interface{}{"ipaddress": "10.0.0.10"}}},
+				map[string]interface{}{"name": "web-10", "memory": float64(2048), "created": "2024-03-01", "nic": []interface{}{map[string]interface{}{"ipaddress": "10.0.0.1"}}},
+			},
+		}
+		var names []string
+		for _, item := range sortResponse(response, test.sortBy)["virtualmachine"].([]interface{}) {
+			names = append(names, item.(map[string]interface{})["name"].(string))
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("sortResponse(%v) = %v, expected %v", test.sortBy, names, test.expected)
+		}
+	}
+}
+
+func TestFlattenRow(t *testing.T) {
+	row := make(map[string]interface{})
+	flattenRow("", map[string]interface{}{
+		"name":    "web-1",
+		"details": map[string]interface{}{"cpu": "2", "disk": map[string]interface{}{"size": float64(10)}},
+		"nic":     []interface{}{map[string]interface{}{"ipaddress": "10.0.0.1"}, map[string]interface{}{"ipaddress": "10.0.0.2"}},
+		"ports":   []interface{}{float64(22), float64(80)},
+		"tags":    []interface{}{},
+		"groups":  []interface{}{[]interface{}{"a", "b"}},
+		"owner":   nil,
+	}, row)
+	expected := map[string]interface{}{
+		"name":              "web-1",
+		"details.cpu":       "2",
+		"details.disk.size": float64(10),
+		"nic.0.ipaddress":   "10.0.0.1",
+		"nic.1.ipaddress":   "10.0.0.2",
+		"ports":             "22,80",
+		"groups.0":          "a,b",
+		"owner":             nil,
+	}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("flattenRow returned %v, expected %v", row, expected)
+	}
+}
+
+func TestTabularRows(t *testing.T) {
+	items := []interface{}{
+		map[string]interface{}{"name": "web-1", "nic": []interface{}{map[string]interface{}{"ipaddress": "10.0.0.1"}, map[string]interface{}{"ipaddress": "10.0.0.2"}}},
+		map[string]interface{}{"name": "db-1", "details": map[string]interface{}{"cpu": "2"}},
+		"plain",
+		map[string]interface{}{},
+	}
+	tests := []struct {
+		value    interface{}
+		filter   []string
+		expected []string
+		rows     int
+	}{
+		{items, nil, []string{"name", "details.cpu", "nic.0.ipaddress", "nic.1.ipaddress"}, 2},
+		{items, []string{"nic", "name"}, []string{"nic.0.ipaddress", "nic.1.ipaddress", "name"}, 2},
+		{items, []string{"details.cpu", "missing"}, []string{"details.cpu", "missing"}, 2},
+		{map[string]interface{}{"name": "zone1", "details": map[string]interface{}{"a": "b"}}, nil, []string{"name", "details.a"}, 1},
+		{"plain", nil, nil, 0},
+	}
+	for _, test := range tests {
+		rows, header := defaultPrinter.tabularRows(test.value, test.filter)
+		if !reflect.DeepEqual(header, test.expected) || len(rows) != test.rows {
+			t.Errorf("tabularRows(%v, %v) = %d rows with %v, expected %d rows with %v", test.value, test.filter, len(rows), header, test.rows, test.expected)
+		}
+	}
+}
+
+func TestExplodeResponse(t *testing.T) {
+	response := map[string]interface{}{
+		"count": float64(2),
+		"virtualmachine": []interface{}{
+			map[string]interface{}{
+				"name": "web-1",
+				"nic":  []interface{}{"nic-a", "nic-b"},
+				"tags": []interface{}{"t1", "t2"},
+			},
+			map[string]interface{}{"name": "db-1", "nic": []interface{}{}, "tags": []interface{}{"t3"}},
+			map[string]interface{}{"name": "db-2"},
+		},
+		"network": map[string]interface{}{"name": "net1", "nic": []interface{}{"nic-c", "nic-d"}},
+	}
+	result := explodeResponse(response, []string{"nic", "tags"})
+	label := func(value interface{}) []string {
+		var labels []string
+		for _, item := range value.([]interface{}) {
+			row := item.(map[string]interface{})
+			labels = append(labels, fmt.Sprintf("%v/%v/%v", row["name"], row["nic"], row["tags"]))
+		}
+		return labels
+	}
+	expected := []string{
+		"web-1/nic-a/t1", "web-1/nic-a/t2", "web-1/nic-b/t1", "web-1/nic-b/t2",
+		"db-1/[]/t3", "db-2/<nil>/<nil>",
+	}
+	if labels := label(result["virtualmachine"]); !reflect.DeepEqual(labels, expected) {
+		t.Errorf("explodeResponse returned %v, expected %v", labels, expected)
+	}
+	if labels := label(result["network"]); !reflect.DeepEqual(labels, []string{"net1/nic-c/<nil>", "net1/nic-d/<nil>"}) {
+		t.Errorf("explodeResponse returned %v for a single object", labels)
+	}
+	if result["count"] != float64(2) {
+		t.Errorf("explodeResponse changed count to %v", result["count"])
+	}
+	if nic := response["virtualmachine"].([]interface{})[0].(map[string]interface{})["nic"]; len(nic.([]interface{})) != 2 {
+		t.Errorf("explodeResponse changed the response it was given")
+	}
+	if result := explodeResponse(response, nil); !reflect.DeepEqual(result, response) {
+		t.Errorf("explodeResponse without fields changed the response")
+	}
+}
+
+func TestPrintCsvObject(t *testing.T) {
+	response := map[string]interface{}{"name": "zone1", "details": map[string]interface{}{"a": "b,c"}}
+	var out bytes.Buffer
+	previous := SetOutputWriter(&out)
+	defaultPrinter.printCsv(response, nil)
+	SetOutputWriter(previous)
+	expected := "key,value\nname,zone1\ndetails.a,\"b,c\"\n"
+	if out.String() != expected {
+		t.Errorf("printCsv printed %q, expected %q", out.String(), expected)
+	}
+}
@@ -0,0 +1,194 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+	"github.com/google/shlex"
+	"golang.org/x/term"
+)
+
+var ansiRegex = regexp.MustCompile("\033\\[[0-9;]*[mK]")
+
+// terminalBuffer buffers formatted results that are shown on the terminal later,
+// such as the frames of watch, so the output keeps its colours
+type terminalBuffer struct {
+	bytes.Buffer
+}
+
+// PageOutput prints the formatted results of a shell command through a pager until
+// the returned function is called, the results are shown in the pager as they arrive
+// once they do not fit the terminal
+func PageOutput(cfg *config.Config) func() {
+	if !cfg.HasShell || !cfg.Core.Pager || outputWriter != os.Stdout || !isTerminal(os.Stdout) {
+		return func() {}
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width < 1 || height < 2 {
+		return func() {}
+	}
+	output := &pager{width: width, height: height}
+	previous := SetOutputWriter(output)
+	return func() {
+		SetOutputWriter(previous)
+		output.Close()
+	}
+}
+
+// countRows returns the number of terminal rows the text takes, including wrapped lines
+func countRows(lines []string, width int) int {
+	rows := 0
+	for _, line := range lines {
+		length := utf8.RuneCountInString(ansiRegex.ReplaceAllString(line, ""))
+		if length == 0 {
+			rows++
+			continue
+		}
+		rows += (length + width - 1) / width
+	}
+	return rows
+}
+
+// pager buffers the formatted results until they do not fit the terminal, and then
+// streams them to $PAGER, less or the internal pager
+type pager struct {
+	buffer  bytes.Buffer
+	width   int
+	height  int
+	paging  bool
+	command *exec.Cmd
+	input   io.WriteCloser
+	// remaining is the number of lines the internal pager shows before it asks for more
+	remaining int
+	quit      bool
+}
+
+func (p *pager) Write(data []byte) (int, error) {
+	if p.quit {
+		return len(data), nil
+	}
+	if p.input != nil {
+		if _, err := p.input.Write(data); err != nil {
+			config.Debug("Pager closed its input: ", err)
+			p.quit = true
+		}
+		return len(data), nil
+	}
+	p.buffer.Write(data)
+	if !p.paging {
+		lines := strings.Split(strings.TrimSuffix(p.buffer.String(), "\n"), "\n")
+		if countRows(lines, p.width) < p.height {
+			return len(data), nil
+		}
+		p.start()
+	}
+	p.showLines(false)
+	return len(data), nil
+}
+
+// start starts paging the buffered output, using the internal pager when the
+// external pager cannot be started
+func (p *pager) start() {
+	p.paging = true
+	p.remaining = p.height - 1
+	if err := p.startPager(); err != nil {
+		config.Debug("Failed to run pager, using the internal pager: ", err)
+		return
+	}
+	p.input.Write(p.buffer.Bytes())
+	p.buffer.Reset()
+}
+
+// startPager starts $PAGER, or less when available, reading the output from a pipe
+func (p *pager) startPager() error {
+	pager := os.Getenv("PAGER")
+	if len(pager) == 0 {
+		if _, err := exec.LookPath("less"); err != nil {
+			return err
+		}
+		pager = "less"
+	}
+	args, err := shlex.Split(pager)
+	if err != nil || len(args) == 0 {
+		return fmt.Errorf("invalid pager %q", pager)
+	}
+	command := exec.Command(args[0], args[1:]...)
+	command.Env = os.Environ()
+	if len(os.Getenv("LESS")) == 0 {
+		command.Env = append(command.Env, "LESS=FRX")
+	}
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	input, err := command.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := command.Start(); err != nil {
+		return err
+	}
+	p.command = command
+	p.input = input
+	return nil
+}
+
+// showLines shows the complete buffered lines a page at a time, asking through the
+// shell for the next page, the last incomplete line is shown once the output ends
+func (p *pager) showLines(final bool) {
+	for !p.quit && p.buffer.Len() > 0 {
+		line, err := p.buffer.ReadString('\n')
+		if err != nil && !final {
+			p.buffer.Reset()
+			p.buffer.WriteString(line)
+			return
+		}
+		rows := countRows([]string{strings.TrimSuffix(line, "\n")}, p.width)
+		if p.remaining < rows && p.remaining < p.height-1 {
+			answer, err := ReadInput("-- More -- enter: next page, q: quit ")
+			if err != nil || strings.EqualFold(answer, "q") {
+				p.quit = true
+				p.buffer.Reset()
+				return
+			}
+			p.remaining = p.height - 1
+		}
+		fmt.Fprint(os.Stdout, line)
+		p.remaining -= rows
+	}
+}
+
+// Close shows the rest of the output and waits for the pager to exit
+func (p *pager) Close() {
+	switch {
+	case p.command != nil:
+		p.input.Close()
+		p.command.Wait()
+	case p.paging:
+		p.showLines(true)
+	default:
+		fmt.Fprint(os.Stdout, p.buffer.String())
+	}
+}
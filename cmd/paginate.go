@@ -0,0 +1,81 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+func getArgValue(args []string, name string) (string, bool) {
+	for _, arg := range args {
+		if parts := strings.SplitN(arg, "=", 2); len(parts) == 2 && parts[0] == name {
+			return parts[1], true
+		}
+	}
+	return "", false
+}
+
+// isStreamable returns true for paginated list API calls that provide a positive pagesize
+// but no page, a pagesize of -1 lists all items in a single response
+func isStreamable(api *config.API, args []string) bool {
+	if !api.HasArg("page") || !api.HasArg("pagesize") {
+		return false
+	}
+	_, hasPage := getArgValue(args, "page")
+	value, hasPageSize := getArgValue(args, "pagesize")
+	pageSize, err := strconv.Atoi(value)
+	return hasPageSize && !hasPage && err == nil && pageSize > 0
+}
+
+// streamPages fetches the pages of a list API one by one and prints the items of every page as it arrives
+func streamPages(r *Request, api *config.API, args []string, outputType string, options outputOptions) error {
+	value, _ := getArgValue(args, "pagesize")
+	pageSize, _ := strconv.Atoi(value)
+
+	fetched := 0
+	for page := 1; ; page++ {
+		pageArgs := append(append([]string{}, args...), "page="+strconv.Itoa(page))
+		response, err := NewAPIRequest(r, api.Name, pageArgs, api.Async)
+		if err != nil {
+			if strings.HasSuffix(err.Error(), "context canceled") {
+				return nil
+			}
+			return err
+		}
+
+		items := 0
+		for _, v := range response {
+			if list, ok := v.([]interface{}); ok {
+				items += len(list)
+			}
+		}
+		if items == 0 {
+			return nil
+		}
+		printResult(outputType, response, options)
+
+		fetched += items
+		count, hasCount := response["count"].(float64)
+		if items < pageSize || (hasCount && fetched >= int(count)) {
+			return nil
+		}
+	}
+}
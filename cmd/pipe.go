@@ -0,0 +1,159 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type pipeFilter func(args []string, in io.Reader, out io.Writer) error
+
+// pipeFilters are the built-in commands that can be used after a | in the shell
+var pipeFilters = map[string]pipeFilter{
+	"count": countFilter,
+	"grep":  grepFilter,
+	"head":  headFilter,
+	"query": queryFilter,
+}
+
+// IsPipeFilter returns true if a built-in pipe filter exists with the name
+func IsPipeFilter(name string) bool {
+	_, ok := pipeFilters[name]
+	return ok
+}
+
+// RunPipeFilter runs a built-in pipe filter on the output of the previous command
+func RunPipeFilter(args []string, in io.Reader, out io.Writer) error {
+	filter, ok := pipeFilters[args[0]]
+	if !ok {
+		return errors.New("unknown filter " + args[0])
+	}
+	return filter(args[1:], in, out)
+}
+
+// grepFilter prints the lines matching a regular expression, -i ignores case and -v inverts the match
+func grepFilter(args []string, in io.Reader, out io.Writer) error {
+	ignoreCase, invert := false, false
+	var pattern string
+	for _, arg := range args {
+		switch arg {
+		case "-i":
+			ignoreCase = true
+		case "-v":
+			invert = true
+		default:
+			pattern = arg
+		}
+	}
+	if len(pattern) == 0 {
+		return errors.New("usage: grep [-i] [-v] <pattern>")
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if matcher.MatchString(scanner.Text()) != invert {
+			fmt.Fprintln(out, scanner.Text())
+		}
+	}
+	return scanner.Err()
+}
+
+// headFilter prints the first lines, 10 unless -n <lines> or -<lines> is given
+func headFilter(args []string, in io.Reader, out io.Writer) error {
+	lines := 10
+	value := strings.TrimPrefix(strings.Join(args, ""), "-")
+	value = strings.TrimPrefix(value, "n")
+	if len(value) > 0 {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return errors.New("usage: head [-n <lines>]")
+		}
+		lines = count
+	}
+	scanner := bufio.NewScanner(in)
+	for idx := 0; idx < lines && scanner.Scan(); idx++ {
+		fmt.Fprintln(out, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// countFilter prints the number of list items of a JSON response, or the number of lines
+func countFilter(args []string, in io.Reader, out io.Writer) error {
+	input, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	if err := json.Unmarshal(input, &data); err == nil {
+		switch value := data.(type) {
+		case []interface{}:
+			fmt.Fprintln(out, len(value))
+			return nil
+		case map[string]interface{}:
+			count, hasList := 0, false
+			for _, item := range value {
+				if items, ok := item.([]interface{}); ok {
+					count += len(items)
+					hasList = true
+				}
+			}
+			if hasList {
+				fmt.Fprintln(out, count)
+				return nil
+			}
+		}
+	}
+	count := 0
+	for _, line := range strings.Split(string(input), "\n") {
+		if len(strings.TrimSpace(line)) > 0 {
+			count++
+		}
+	}
+	fmt.Fprintln(out, count)
+	return nil
+}
+
+// queryFilter applies a JMESPath-like query on JSON output
+func queryFilter(args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: query <expression>")
+	}
+	node, err := compileQuery(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	if err := json.NewDecoder(in).Decode(&data); err != nil {
+		return errors.New("query expects JSON input, use -o json: " + err.Error())
+	}
+	fmt.Fprintln(out, marshalJSON(node(data), true, "  "))
+	return nil
+}
@@ -0,0 +1,905 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query expressions are a JMESPath-like language to select and reshape responses,
+// for example: virtualmachine[?state=='Running'].{name:name,ip:nic[0].ipaddress}
+//
+// Supported are field access, quoted "fields", indexes and slices, [*] and .*
+// projections, [] flattening, [?cond] filters with comparisons, && || and !,
+// pipes, multi-select lists and hashes, 'raw strings', `json` literals, numbers
+// and the functions listed in queryFunctions. Unlike JMESPath, accessing a field
+// of a list projects it over the list items, so nic.ipaddress works as nic[*].ipaddress.
+
+type queryNode func(value interface{}) interface{}
+
+type queryToken struct {
+	kind  string
+	value string
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func tokenizeQuery(expression string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, queryToken{"ident", string(runes[start:i])})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, queryToken{"number", string(runes[start:i])})
+		case r == '\'' || r == '"' || r == '`':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated %c in query", r)
+			}
+			value := strings.ReplaceAll(string(runes[i+1:end]), "\\"+string(r), string(r))
+			kind := map[rune]string{'\'': "string", '"': "quoted", '`': "literal"}[r]
+			tokens = append(tokens, queryToken{kind, value})
+			i = end + 1
+		default:
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "||", "&&":
+				tokens = append(tokens, queryToken{two, two})
+				i += 2
+				continue
+			}
+			if !strings.ContainsRune(".[]{}(),:|!<>@*?&", r) {
+				return nil, fmt.Errorf("unexpected character %c in query", r)
+			}
+			tokens = append(tokens, queryToken{string(r), string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func (p *queryParser) peek() queryToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return queryToken{"eof", ""}
+}
+
+func (p *queryParser) peekAt(offset int) queryToken {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return queryToken{"eof", ""}
+}
+
+func (p *queryParser) next() queryToken {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *queryParser) expect(kind string) (queryToken, error) {
+	token := p.next()
+	if token.kind != kind {
+		return token, fmt.Errorf("expected %s but found %s in query", kind, token)
+	}
+	return token, nil
+}
+
+func (t queryToken) String() string {
+	if t.kind == "eof" {
+		return "end of expression"
+	}
+	return "'" + t.value + "'"
+}
+
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+func identity(value interface{}) interface{} {
+	return value
+}
+
+func getField(value interface{}, name string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v[name]
+	case []interface{}:
+		var result []interface{}
+		for _, item := range v {
+			if field := getField(item, name); field != nil {
+				result = append(result, field)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func project(items interface{}, right queryNode) interface{} {
+	list, ok := items.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := []interface{}{}
+	for _, item := range list {
+		if value := right(item); value != nil {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func flatten(value interface{}) interface{} {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := []interface{}{}
+	for _, item := range list {
+		if inner, ok := item.([]interface{}); ok {
+			result = append(result, inner...)
+		} else {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func compareValues(op string, left interface{}, right interface{}) interface{} {
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right) || fmt.Sprint(left) == fmt.Sprint(right) && left != nil && right != nil
+	case "!=":
+		return !(reflect.DeepEqual(left, right) || fmt.Sprint(left) == fmt.Sprint(right) && left != nil && right != nil)
+	}
+	var cmp int
+	leftNumber, leftOk := toNumber(left)
+	rightNumber, rightOk := toNumber(right)
+	if leftOk && rightOk {
+		switch {
+		case leftNumber < rightNumber:
+			cmp = -1
+		case leftNumber > rightNumber:
+			cmp = 1
+		}
+	} else {
+		leftString, leftOk := left.(string)
+		rightString, rightOk := right.(string)
+		if !leftOk || !rightOk {
+			return nil
+		}
+		cmp = strings.Compare(leftString, rightString)
+	}
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return nil
+}
+
+func (p *queryParser) parseExpression() (queryNode, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "|" {
+		p.next()
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		first, second := left, right
+		left = func(value interface{}) interface{} {
+			return second(first(value))
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		first, second := left, right
+		left = func(value interface{}) interface{} {
+			if result := first(value); isTruthy(result) {
+				return result
+			}
+			return second(value)
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "&&" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		first, second := left, right
+		left = func(value interface{}) interface{} {
+			if result := first(value); !isTruthy(result) {
+				return result
+			}
+			return second(value)
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.peek().kind == "!" {
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return !isTruthy(inner(value))
+		}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (queryNode, error) {
+	left, err := p.parseChain()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek().kind; op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.parseChain()
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return compareValues(op, left(value), right(value))
+		}, nil
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseChain() (queryNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return p.parsePostfix(left, false)
+}
+
+func (p *queryParser) parseMultiSelectList() (queryNode, error) {
+	var items []queryNode
+	for {
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.peek().kind != "," {
+			break
+		}
+		p.next()
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return func(value interface{}) interface{} {
+		if value == nil {
+			return nil
+		}
+		result := []interface{}{}
+		for _, item := range items {
+			result = append(result, item(value))
+		}
+		return result
+	}, nil
+}
+
+func (p *queryParser) parseMultiSelectHash() (queryNode, error) {
+	var keys []string
+	var items []queryNode
+	for {
+		key := p.next()
+		if key.kind != "ident" && key.kind != "quoted" && key.kind != "string" {
+			return nil, fmt.Errorf("expected key but found %s in query", key)
+		}
+		if _, err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.value)
+		items = append(items, item)
+		if p.peek().kind != "," {
+			break
+		}
+		p.next()
+	}
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return func(value interface{}) interface{} {
+		if value == nil {
+			return nil
+		}
+		result := make(map[string]interface{})
+		for idx, key := range keys {
+			result[key] = items[idx](value)
+		}
+		return result
+	}, nil
+}
+
+func (p *queryParser) parseFunction(name string) (queryNode, error) {
+	function, ok := queryFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s() in query", name)
+	}
+	var args []queryNode
+	for p.peek().kind != ")" {
+		if len(args) > 0 {
+			if _, err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+	if len(args) != function.arity {
+		return nil, fmt.Errorf("function %s() takes %d argument(s) but %d were given in query", name, function.arity, len(args))
+	}
+	return func(value interface{}) interface{} {
+		var values []interface{}
+		for _, arg := range args {
+			values = append(values, arg(value))
+		}
+		return function.call(values)
+	}, nil
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	token := p.peek()
+	switch token.kind {
+	case "ident", "quoted":
+		p.next()
+		if p.peek().kind == "(" && token.kind == "ident" {
+			p.next()
+			return p.parseFunction(token.value)
+		}
+		return func(value interface{}) interface{} {
+			return getField(value, token.value)
+		}, nil
+	case "string":
+		p.next()
+		return func(value interface{}) interface{} {
+			return token.value
+		}, nil
+	case "number":
+		p.next()
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return number
+		}, nil
+	case "literal":
+		p.next()
+		var literal interface{}
+		if err := json.Unmarshal([]byte(token.value), &literal); err != nil {
+			literal = token.value
+		}
+		return func(value interface{}) interface{} {
+			return literal
+		}, nil
+	case "@":
+		p.next()
+		return identity, nil
+	case "*":
+		p.next()
+		right, err := p.parsePostfix(identity, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return project(objectValues(value), right)
+		}, nil
+	case "(":
+		p.next()
+		inner, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect(")")
+		return inner, err
+	case "{":
+		p.next()
+		return p.parseMultiSelectHash()
+	case "[":
+		switch p.peekAt(1).kind {
+		case "number", "*", "]", "?", ":":
+			return p.parsePostfix(identity, false)
+		}
+		p.next()
+		return p.parseMultiSelectList()
+	}
+	return nil, fmt.Errorf("unexpected %s in query", token)
+}
+
+func objectValues(value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := []interface{}{}
+	for _, key := range keys {
+		result = append(result, object[key])
+	}
+	return result
+}
+
+func (p *queryParser) parseBracket(left queryNode) (queryNode, error) {
+	p.next()
+	token := p.peek()
+	switch {
+	case token.kind == "*" && p.peekAt(1).kind == "]":
+		p.pos += 2
+		right, err := p.parsePostfix(identity, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return project(left(value), right)
+		}, nil
+	case token.kind == "]":
+		p.next()
+		right, err := p.parsePostfix(identity, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return project(flatten(left(value)), right)
+		}, nil
+	case token.kind == "?":
+		p.next()
+		condition, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		right, err := p.parsePostfix(identity, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return project(left(value), func(item interface{}) interface{} {
+				if isTruthy(condition(item)) {
+					return right(item)
+				}
+				return nil
+			})
+		}, nil
+	case token.kind == "number" || token.kind == ":":
+		var bounds []*int
+		current := (*int)(nil)
+		for p.peek().kind != "]" {
+			token := p.next()
+			switch token.kind {
+			case "number":
+				number, err := strconv.Atoi(token.value)
+				if err != nil {
+					return nil, err
+				}
+				current = &number
+			case ":":
+				if len(bounds) > 0 {
+					return nil, errors.New("slice steps are not supported in query")
+				}
+				bounds = append(bounds, current)
+				current = nil
+			default:
+				return nil, fmt.Errorf("unexpected %s in index", token)
+			}
+		}
+		p.next()
+		if len(bounds) == 0 {
+			if current == nil {
+				return nil, errors.New("empty index in query")
+			}
+			index := *current
+			return func(value interface{}) interface{} {
+				list, ok := left(value).([]interface{})
+				if !ok {
+					return nil
+				}
+				position := index
+				if position < 0 {
+					position += len(list)
+				}
+				if position < 0 || position >= len(list) {
+					return nil
+				}
+				return list[position]
+			}, nil
+		}
+		bounds = append(bounds, current)
+		right, err := p.parsePostfix(identity, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			list, ok := left(value).([]interface{})
+			if !ok {
+				return nil
+			}
+			start, end := 0, len(list)
+			if bounds[0] != nil {
+				start = clampIndex(*bounds[0], len(list))
+			}
+			if len(bounds) > 1 && bounds[1] != nil {
+				end = clampIndex(*bounds[1], len(list))
+			}
+			if start > end {
+				start = end
+			}
+			return project(list[start:end], right)
+		}, nil
+	}
+	return nil, fmt.Errorf("unexpected %s in query", token)
+}
+
+func clampIndex(index int, length int) int {
+	if index < 0 {
+		index += length
+	}
+	if index < 0 {
+		return 0
+	}
+	if index > length {
+		return length
+	}
+	return index
+}
+
+// parsePostfix parses field access, indexes and projections following an expression,
+// the right side of a projection stops at [] so that it flattens the whole projection
+func (p *queryParser) parsePostfix(left queryNode, inProjection bool) (queryNode, error) {
+	for {
+		switch p.peek().kind {
+		case ".":
+			p.next()
+			token := p.peek()
+			switch token.kind {
+			case "ident", "quoted":
+				p.next()
+				current := left
+				left = func(value interface{}) interface{} {
+					return getField(current(value), token.value)
+				}
+			case "*":
+				p.next()
+				right, err := p.parsePostfix(identity, true)
+				if err != nil {
+					return nil, err
+				}
+				current := left
+				left = func(value interface{}) interface{} {
+					return project(objectValues(current(value)), right)
+				}
+			case "{", "[":
+				p.next()
+				var selection queryNode
+				var err error
+				if token.kind == "{" {
+					selection, err = p.parseMultiSelectHash()
+				} else {
+					selection, err = p.parseMultiSelectList()
+				}
+				if err != nil {
+					return nil, err
+				}
+				current := left
+				left = func(value interface{}) interface{} {
+					return selection(current(value))
+				}
+			default:
+				return nil, fmt.Errorf("unexpected %s after '.' in query", token)
+			}
+		case "[":
+			if inProjection && p.peekAt(1).kind == "]" {
+				return left, nil
+			}
+			node, err := p.parseBracket(left)
+			if err != nil {
+				return nil, err
+			}
+			left = node
+		default:
+			return left, nil
+		}
+	}
+}
+
+// queryFunction is a query function along with the number of arguments it takes
+type queryFunction struct {
+	arity int
+	call  func(args []interface{}) interface{}
+}
+
+var queryFunctions = map[string]queryFunction{
+	"length": {1, func(args []interface{}) interface{} {
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v)))
+		case []interface{}:
+			return float64(len(v))
+		case map[string]interface{}:
+			return float64(len(v))
+		}
+		return nil
+	}},
+	"keys": {1, func(args []interface{}) interface{} {
+		object, ok := args[0].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var keys []string
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := []interface{}{}
+		for _, key := range keys {
+			result = append(result, key)
+		}
+		return result
+	}},
+	"values": {1, func(args []interface{}) interface{} {
+		return objectValues(args[0])
+	}},
+	"contains": {2, func(args []interface{}) interface{} {
+		switch v := args[0].(type) {
+		case string:
+			search, ok := args[1].(string)
+			return ok && strings.Contains(v, search)
+		case []interface{}:
+			for _, item := range v {
+				if reflect.DeepEqual(item, args[1]) {
+					return true
+				}
+			}
+		}
+		return false
+	}},
+	"starts_with": {2, func(args []interface{}) interface{} {
+		value, ok1 := args[0].(string)
+		prefix, ok2 := args[1].(string)
+		return ok1 && ok2 && strings.HasPrefix(value, prefix)
+	}},
+	"ends_with": {2, func(args []interface{}) interface{} {
+		value, ok1 := args[0].(string)
+		suffix, ok2 := args[1].(string)
+		return ok1 && ok2 && strings.HasSuffix(value, suffix)
+	}},
+	"join": {2, func(args []interface{}) interface{} {
+		separator, ok1 := args[0].(string)
+		list, ok2 := args[1].([]interface{})
+		if !ok1 || !ok2 {
+			return nil
+		}
+		var parts []string
+		for _, item := range list {
+			parts = append(parts, fmt.Sprintf("%v", item))
+		}
+		return strings.Join(parts, separator)
+	}},
+	"to_string": {1, func(args []interface{}) interface{} {
+		if value, ok := args[0].(string); ok {
+			return value
+		}
+		encoded, _ := json.Marshal(args[0])
+		return string(encoded)
+	}},
+	"to_number": {1, func(args []interface{}) interface{} {
+		if number, ok := toNumber(args[0]); ok {
+			return number
+		}
+		return nil
+	}},
+	"sum": {1, func(args []interface{}) interface{} {
+		list, ok := args[0].([]interface{})
+		if !ok {
+			return nil
+		}
+		sum := 0.0
+		for _, item := range list {
+			if number, ok := toNumber(item); ok {
+				sum += number
+			}
+		}
+		return sum
+	}},
+	"max": {1, func(args []interface{}) interface{} {
+		return extremum(args[0], 1)
+	}},
+	"min": {1, func(args []interface{}) interface{} {
+		return extremum(args[0], -1)
+	}},
+	"sort": {1, func(args []interface{}) interface{} {
+		list, ok := args[0].([]interface{})
+		if !ok {
+			return nil
+		}
+		sorted := append([]interface{}{}, list...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return compareValues("<", sorted[i], sorted[j]) == true
+		})
+		return sorted
+	}},
+}
+
+func extremum(value interface{}, sign int) interface{} {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var result interface{}
+	for _, item := range list {
+		if result == nil || (sign > 0 && compareValues(">", item, result) == true) || (sign < 0 && compareValues("<", item, result) == true) {
+			result = item
+		}
+	}
+	return result
+}
+
+// compileQuery parses a query expression into a function that evaluates it
+func compileQuery(expression string) (queryNode, error) {
+	tokens, err := tokenizeQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+	parser := &queryParser{tokens: tokens}
+	node, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek().kind != "eof" {
+		return nil, fmt.Errorf("unexpected %s in query", parser.peek())
+	}
+	return node, nil
+}
+
+var queryKeyRegex = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// queryResponse evaluates a query expression on a response, results that are
+// not objects are returned under the response key the query starts with
+func queryResponse(response map[string]interface{}, expression string) (map[string]interface{}, error) {
+	node, err := compileQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+	result := node(response)
+	if object, ok := result.(map[string]interface{}); ok {
+		return object, nil
+	}
+	key := "result"
+	if match := queryKeyRegex.FindStringSubmatch(expression); match != nil {
+		if _, ok := response[match[1]]; ok {
+			key = match[1]
+		}
+	}
+	if result == nil {
+		return map[string]interface{}{}, nil
+	}
+	return map[string]interface{}{key: result}, nil
+}
@@ -0,0 +1,148 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const queryTestResponse = `{
+	"count": 3,
+	"virtualmachine": [
+		{"name": "web-1", "state": "Running", "memory": 2048, "nic": [{"ipaddress": "10.0.0.1"}, {"ipaddress": "10.0.1.1"}], "tags": [{"key": "env", "value": "prod"}]},
+		{"name": "db-1", "state": "Stopped", "memory": 8192, "nic": [{"ipaddress": "10.0.0.2"}]},
+		{"name": "web-10", "state": "Running", "memory": 4096, "nic": [{"ipaddress": "10.0.0.3"}]}
+	]
+}`
+
+func queryTestData(t *testing.T) map[string]interface{} {
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(queryTestResponse), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestCompileQuery(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{`virtualmachine[?state=='Running'].{name:name,ip:nic[0].ipaddress}`, `[{"ip":"10.0.0.1","name":"web-1"},{"ip":"10.0.0.3","name":"web-10"}]`},
+		{`count`, `3`},
+		{`virtualmachine[0].name`, `"web-1"`},
+		{`virtualmachine[-1].name`, `"web-10"`},
+		{`virtualmachine[5].name`, `null`},
+		{`virtualmachine[*].name`, `["web-1","db-1","web-10"]`},
+		{`virtualmachine.name`, `["web-1","db-1","web-10"]`},
+		{`virtualmachine[].nic[].ipaddress`, `["10.0.0.1","10.0.1.1","10.0.0.2","10.0.0.3"]`},
+		{`virtualmachine[*].[name, memory]`, `[["web-1",2048],["db-1",8192],["web-10",4096]]`},
+		{`virtualmachine[1:].name`, `["db-1","web-10"]`},
+		{`virtualmachine[:1].name`, `["web-1"]`},
+		{`virtualmachine[-2:].name`, `["db-1","web-10"]`},
+		{`virtualmachine[?memory > ` + "`4000`" + `].name`, `["db-1","web-10"]`},
+		{`virtualmachine[?state=='Running' && memory < ` + "`4000`" + `].name`, `["web-1"]`},
+		{`virtualmachine[?state=='Stopped' || starts_with(name, 'web-1')].name`, `["web-1","db-1","web-10"]`},
+		{`virtualmachine[?!(state=='Running')].name`, `["db-1"]`},
+		{`virtualmachine[?tags].name`, `["web-1"]`},
+		{`virtualmachine[*].name | [0]`, `"web-1"`},
+		{`length(virtualmachine)`, `3`},
+		{`sum(virtualmachine[*].memory)`, `14336`},
+		{`max(virtualmachine[*].memory)`, `8192`},
+		{`min(virtualmachine[*].name)`, `"db-1"`},
+		{`sort(virtualmachine[*].name)`, `["db-1","web-1","web-10"]`},
+		{`join(', ', virtualmachine[*].name)`, `"web-1, db-1, web-10"`},
+		{`keys(virtualmachine[0].tags[0])`, `["key","value"]`},
+		{`contains(virtualmachine[*].name, 'db-1')`, `true`},
+	}
+	response := queryTestData(t)
+	for _, test := range tests {
+		node, err := compileQuery(test.expression)
+		if err != nil {
+			t.Errorf("compileQuery(%q) failed: %v", test.expression, err)
+			continue
+		}
+		result, _ := json.Marshal(node(response))
+		if string(result) != test.expected {
+			t.Errorf("compileQuery(%q) = %s, expected %s", test.expression, result, test.expected)
+		}
+	}
+}
+
+func TestCompileQueryErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{`keys()`, "takes 1 argument(s) but 0 were given"},
+		{`sum()`, "takes 1 argument(s) but 0 were given"},
+		{`sort()`, "takes 1 argument(s) but 0 were given"},
+		{`max()`, "takes 1 argument(s) but 0 were given"},
+		{`min()`, "takes 1 argument(s) but 0 were given"},
+		{`contains(name)`, "takes 2 argument(s) but 1 were given"},
+		{`length(a b)`, "expected ,"},
+		{`length(a,)`, "unexpected ')'"},
+		{`length(a`, "expected ,"},
+		{`unknown(a)`, "unknown function"},
+		{`virtualmachine[::2]`, "slice steps are not supported"},
+		{`virtualmachine[0`, "unexpected end of expression"},
+		{`virtualmachine[?state=='Running'`, "expected ]"},
+		{`name == 'web`, "unterminated '"},
+		{`name $ 1`, "unexpected character $"},
+		{`name name`, "unexpected 'name'"},
+	}
+	for _, test := range tests {
+		_, err := compileQuery(test.expression)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("compileQuery(%q) returned error %v, expected %q", test.expression, err, test.err)
+		}
+	}
+}
+
+func TestQueryResponse(t *testing.T) {
+	response := queryTestData(t)
+	result, err := queryResponse(response, `virtualmachine[?state=='Stopped'].name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"virtualmachine": []interface{}{"db-1"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("queryResponse returned %v, expected %v", result, expected)
+	}
+
+	result, err = queryResponse(response, `virtualmachine[0].{name:name}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]interface{}{"name": "web-1"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("queryResponse returned %v, expected %v", result, expected)
+	}
+
+	result, err = queryResponse(response, `length(virtualmachine)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]interface{}{"result": float64(3)}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("queryResponse returned %v, expected %v", result, expected)
+	}
+}
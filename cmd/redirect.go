@@ -0,0 +1,70 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/term"
+)
+
+// outputWriter is where the formatted results are printed, spinner and debug
+// messages always go to stdout
+var outputWriter io.Writer = os.Stdout
+
+func isTerminal(writer io.Writer) bool {
+	switch writer.(type) {
+	case *terminalBuffer, *pager:
+		return true
+	}
+	file, ok := writer.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// SetOutputWriter prints the formatted results to a writer and returns the previous writer
+func SetOutputWriter(writer io.Writer) io.Writer {
+	previous := outputWriter
+	outputWriter = writer
+	return previous
+}
+
+// RedirectOutput prints the formatted results to a file until the returned
+// function is called, the file is truncated unless appendToFile is set
+func RedirectOutput(path string, appendToFile bool) (func() error, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendToFile {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	config.Debug("Redirecting output to ", path)
+	previous := SetOutputWriter(file)
+	return func() error {
+		SetOutputWriter(previous)
+		return file.Close()
+	}, nil
+}
@@ -31,20 +31,34 @@ func init() {
 		Name: "set",
 		Help: "Configures options for cmk",
 		SubCommands: map[string][]string{
-			"prompt":       {"🐵", "🐱", "random"},
-			"asyncblock":   {"true", "false"},
-			"timeout":      {"600", "1800", "3600"},
-			"output":       config.GetOutputFormats(),
-			"profile":      {},
-			"url":          {},
-			"username":     {},
-			"password":     {},
-			"domain":       {},
-			"apikey":       {},
-			"secretkey":    {},
-			"verifycert":   {"true", "false"},
-			"debug":        {"true", "false"},
-			"autocomplete": {"true", "false"},
+			"prompt":        {"🐵", "🐱", "random"},
+			"asyncblock":    {"true", "false"},
+			"timeout":       {"600", "1800", "3600"},
+			"output":        config.GetOutputFormats(),
+			"profile":       {},
+			"url":           {},
+			"username":      {},
+			"password":      {},
+			"domain":        {},
+			"apikey":        {},
+			"secretkey":     {},
+			"verifycert":    {"true", "false"},
+			"debug":         {"true", "false"},
+			"autocomplete":  {"true", "false"},
+			"validateargs":  {config.VALIDATE_ERROR, config.VALIDATE_WARN, config.VALIDATE_OFF},
+			"ndjsonkey":     {"true", "false"},
+			"fieldorder":    {},
+			"wide":          {"true", "false"},
+			"humanize":      {"true", "false"},
+			"timeformat":    {config.TIME_RELATIVE, config.TIME_LOCAL},
+			"theme":         {config.THEME_DEFAULT, config.THEME_VIVID, config.THEME_NONE},
+			"pager":         {"true", "false"},
+			"truncate":      {"true", "false"},
+			"confirm":       {"true", "false"},
+			"confirmverbs":  {},
+			"readonly":      {"true", "false"},
+			"readonlyallow": {},
+			"readonlydeny":  {},
 		},
 		Handle: func(r *Request) error {
 			if len(r.Args) < 1 {
@@ -61,6 +75,9 @@ func init() {
 			if subCommand == "display" {
 				subCommand = "output"
 			}
+			if r.Config.IsReadOnly() && strings.HasPrefix(subCommand, "readonly") {
+				return errors.New("Cannot change " + subCommand + " in the read-only profile " + r.Config.Core.ProfileName + ", edit the config file instead")
+			}
 			validArgs := r.Command.SubCommands[subCommand]
 			if len(validArgs) != 0 && subCommand != "timeout" {
 				if !config.CheckIfValuePresent(validArgs, value) {
@@ -32,6 +32,9 @@ func init() {
 			if err != nil {
 				return err
 			}
+			if version := fetchServerVersion(r); len(version) > 0 {
+				response["cloudstackversion"] = version
+			}
 			fmt.Printf("Discovered %v APIs\n", r.Config.UpdateCache(response))
 			r.Config.SaveCache(response)
 			return nil
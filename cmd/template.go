@@ -0,0 +1,119 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var templateFuncs = template.FuncMap{
+	"join": func(items interface{}, separator string) string {
+		list, ok := items.([]interface{})
+		if !ok {
+			return jsonify(items, "")
+		}
+		var values []string
+		for _, item := range list {
+			values = append(values, jsonify(item, ""))
+		}
+		return strings.Join(values, separator)
+	},
+	"upper": func(value interface{}) string {
+		return strings.ToUpper(jsonify(value, ""))
+	},
+	"lower": func(value interface{}) string {
+		return strings.ToLower(jsonify(value, ""))
+	},
+	"default": func(defaultValue interface{}, value interface{}) interface{} {
+		if !isTruthy(value) {
+			return defaultValue
+		}
+		return value
+	},
+	"json": func(value interface{}) string {
+		return marshalJSON(value, false, "")
+	},
+	"date": func(layout string, value interface{}) string {
+		date, ok := parseSortDate(jsonify(value, ""))
+		if !ok {
+			return jsonify(value, "")
+		}
+		return date.Local().Format(layout)
+	},
+	"now": time.Now,
+}
+
+// parseOutputTemplate parses a template string, or the content of a file for @file
+func parseOutputTemplate(text string) (*template.Template, error) {
+	if len(text) == 0 {
+		return nil, errors.New("please provide a template using template=<template string or @file>")
+	}
+	if strings.HasPrefix(text, "@") {
+		content, err := ioutil.ReadFile(text[1:])
+		if err != nil {
+			return nil, err
+		}
+		text = string(content)
+	}
+	return template.New("output").Funcs(templateFuncs).Parse(text)
+}
+
+func executeTemplate(outputTemplate *template.Template, data interface{}) {
+	var out strings.Builder
+	if err := outputTemplate.Execute(&out, data); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to execute template:", err)
+		return
+	}
+	result := out.String()
+	if !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+	fmt.Fprint(outputWriter, result)
+}
+
+// printTemplate evaluates the template for every list item, or for the whole response if it has no list
+func (p *printer) printTemplate(response map[string]interface{}, outputTemplate *template.Template) {
+	if outputTemplate == nil {
+		return
+	}
+	outputTemplate.Funcs(template.FuncMap{
+		"json": func(value interface{}) string {
+			return p.marshalJSON(value, false, "")
+		},
+	})
+	hasList := false
+	for _, key := range p.orderedKeys(response) {
+		items, ok := response[key].([]interface{})
+		if !ok {
+			continue
+		}
+		hasList = true
+		for _, item := range items {
+			executeTemplate(outputTemplate, item)
+		}
+	}
+	if !hasList {
+		executeTemplate(outputTemplate, response)
+	}
+}
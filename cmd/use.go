@@ -0,0 +1,211 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func isUUID(value string) bool {
+	return uuidRegex.MatchString(value)
+}
+
+// resolveContextID finds the id and name of a project or domain by its name, path or id
+func resolveContextID(r *Request, api string, value string) (string, string, error) {
+	args := []string{"listall=true"}
+	if isUUID(value) {
+		args = append(args, "id="+value)
+	} else if !strings.Contains(value, "/") {
+		args = append(args, "name="+value)
+	}
+
+	spinner := r.Config.StartSpinner("looking up " + value + ", please wait...")
+	response, err := NewAPIRequest(r, api, args, false)
+	r.Config.StopSpinner(spinner)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, v := range response {
+		items, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range items {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := row["id"].(string)
+			name, _ := row["name"].(string)
+			path, _ := row["path"].(string)
+			if id == value || strings.EqualFold(name, value) || strings.EqualFold(path, value) {
+				return id, name, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unable to find %s using %s", value, api)
+}
+
+// resolveAccount finds an account by its name within the domain context, or else in
+// any domain, and returns its name along with the id and name of its domain
+func resolveAccount(r *Request, value string) (string, string, string, error) {
+	args := []string{"listall=true", "name=" + value}
+	domainID := r.Config.ActiveProfile.DomainID
+	if len(domainID) > 0 {
+		args = append(args, "domainid="+domainID)
+	}
+
+	spinner := r.Config.StartSpinner("looking up " + value + ", please wait...")
+	response, err := NewAPIRequest(r, "listAccounts", args, false)
+	r.Config.StopSpinner(spinner)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var matches []map[string]interface{}
+	items, _ := response["account"].([]interface{})
+	for _, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := row["name"].(string)
+		rowDomainID, _ := row["domainid"].(string)
+		if strings.EqualFold(name, value) && (len(domainID) == 0 || rowDomainID == domainID) {
+			matches = append(matches, row)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", "", "", fmt.Errorf("unable to find account %s using listAccounts", value)
+	case 1:
+		name, _ := matches[0]["name"].(string)
+		id, _ := matches[0]["domainid"].(string)
+		domain, _ := matches[0]["domain"].(string)
+		return name, id, domain, nil
+	}
+	return "", "", "", fmt.Errorf("account %s exists in more than one domain, set the domain first with 'use domain <name>'", value)
+}
+
+func init() {
+	AddCommand(&Command{
+		Name: "use",
+		Help: "Sets default project, domain or account context",
+		SubCommands: map[string][]string{
+			"project": {},
+			"domain":  {},
+			"account": {},
+			"none":    {},
+		},
+		Handle: func(r *Request) error {
+			if len(r.Args) < 1 {
+				context := r.Config.GetContext()
+				if len(context) == 0 {
+					context = "none"
+				}
+				fmt.Println("Active context:", context)
+				return nil
+			}
+			if r.Args[len(r.Args)-1] == "-h" {
+				fmt.Println("Usage: use project|domain|account <name or id>. Use 'none' as the value to clear a context, or 'use none' to clear all. An account also sets the domain it belongs to, a project and an account replace each other.")
+				return nil
+			}
+
+			subCommand := r.Args[0]
+			value := strings.Trim(strings.Join(r.Args[1:], " "), " ")
+			config.Debug("Use command received:", subCommand, " values:", value)
+
+			if subCommand == "none" {
+				for _, key := range []string{"projectid", "projectname", "domainid", "domainname"} {
+					r.Config.UpdateConfig(key, "", false)
+				}
+				r.Config.UpdateConfig("account", "", true)
+				return nil
+			}
+			if _, ok := r.Command.SubCommands[subCommand]; !ok {
+				return errors.New("Invalid context " + subCommand + ". Supported values: project, domain, account, none")
+			}
+			if len(value) == 0 {
+				return errors.New("please provide a name or id for the " + subCommand)
+			}
+
+			if value == "none" {
+				switch subCommand {
+				case "project":
+					r.Config.UpdateConfig("projectname", "", false)
+					r.Config.UpdateConfig("projectid", "", true)
+				case "domain":
+					// an account context is only valid along with its domain
+					r.Config.UpdateConfig("domainname", "", false)
+					r.Config.UpdateConfig("account", "", false)
+					r.Config.UpdateConfig("domainid", "", true)
+				case "account":
+					r.Config.UpdateConfig("account", "", true)
+				}
+				return nil
+			}
+
+			switch subCommand {
+			case "project":
+				id, name, err := resolveContextID(r, "listProjects", value)
+				if err != nil {
+					return err
+				}
+				// CloudStack does not accept an account along with a project
+				r.Config.UpdateConfig("account", "", false)
+				r.Config.UpdateConfig("projectname", name, false)
+				r.Config.UpdateConfig("projectid", id, true)
+			case "domain":
+				id, name, err := resolveContextID(r, "listDomains", value)
+				if err != nil {
+					return err
+				}
+				if id != r.Config.ActiveProfile.DomainID {
+					r.Config.UpdateConfig("account", "", false)
+				}
+				r.Config.UpdateConfig("domainname", name, false)
+				r.Config.UpdateConfig("domainid", id, true)
+			case "account":
+				account, domainID, domainName, err := resolveAccount(r, value)
+				if err != nil {
+					return err
+				}
+				if domainID != r.Config.ActiveProfile.DomainID {
+					r.Config.UpdateConfig("domainname", domainName, false)
+					r.Config.UpdateConfig("domainid", domainID, false)
+				}
+				r.Config.UpdateConfig("projectname", "", false)
+				r.Config.UpdateConfig("projectid", "", false)
+				r.Config.UpdateConfig("account", account, true)
+			}
+
+			if r.Config.HasShell {
+				fmt.Println("Active context:", r.Config.GetContext())
+			}
+			return nil
+		},
+	})
+}
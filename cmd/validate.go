@@ -0,0 +1,220 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+var mapArgRegex = regexp.MustCompile(`^([a-zA-Z0-9_]+)\[([0-9]+)\]\.([a-zA-Z0-9_]+)$`)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339,
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j] + 1
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// suggestArg finds the closest known arg name for an unknown one
+func suggestArg(api *config.API, name string) string {
+	suggestion := ""
+	best := 3
+	for _, arg := range api.Args {
+		argName := strings.TrimSuffix(arg.Name, "=")
+		if distance := levenshtein(name, argName); distance < best {
+			best = distance
+			suggestion = argName
+		}
+	}
+	return suggestion
+}
+
+func findArg(api *config.API, name string) *config.APIArg {
+	for _, arg := range api.Args {
+		if arg.Name == name+"=" {
+			return arg
+		}
+	}
+	return nil
+}
+
+func validateValue(arg *config.APIArg, name string, value string) string {
+	switch strings.ToLower(arg.Type) {
+	case "uuid":
+		// projectid=-1 lists the resources of all projects
+		if !isUUID(value) && !(name == "projectid" && value == "-1") {
+			return fmt.Sprintf("%s should be a UUID, got '%s'", name, value)
+		}
+	case "integer", "short", "long":
+		bits := map[string]int{"integer": 32, "short": 16, "long": 64}[strings.ToLower(arg.Type)]
+		if _, err := strconv.ParseInt(value, 10, bits); err != nil {
+			return fmt.Sprintf("%s should be of type %s, got '%s'", name, arg.Type, value)
+		}
+	case "float", "double":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("%s should be of type %s, got '%s'", name, arg.Type, value)
+		}
+	case "boolean":
+		if !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false") {
+			return fmt.Sprintf("%s should be true or false, got '%s'", name, value)
+		}
+	case "date", "tzdate":
+		valid := false
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Sprintf("%s should be a date such as yyyy-MM-dd or yyyy-MM-dd'T'HH:mm:ssZ, got '%s'", name, value)
+		}
+	case "map":
+		return fmt.Sprintf("%s is a map, provide it as %s[0].<key>=<value>", name, name)
+	}
+	if arg.Length > 0 && utf8.RuneCountInString(value) > arg.Length {
+		return fmt.Sprintf("%s should not be longer than %d characters", name, arg.Length)
+	}
+	return ""
+}
+
+// serverVersions caches the CloudStack version of the management servers by URL
+var serverVersions = make(map[string]string)
+
+// fetchServerVersion returns the CloudStack version of the management server of the
+// active profile, empty if it cannot be found
+func fetchServerVersion(r *Request) string {
+	url := r.Config.ActiveProfile.URL
+	if version, ok := serverVersions[url]; ok {
+		return version
+	}
+	response, err := NewAPIRequest(r, "listCapabilities", nil, false)
+	if err != nil {
+		config.Debug("Failed to find the server version: ", err)
+		return ""
+	}
+	capability, _ := response["capability"].(map[string]interface{})
+	version, _ := capability["cloudstackversion"].(string)
+	serverVersions[url] = version
+	return version
+}
+
+// isCacheOutdated returns true if the API cache may not match the APIs of the server,
+// as it is the in-built cache or was discovered from a different server version
+func isCacheOutdated(r *Request) bool {
+	if r.Config.IsInBuiltCache() {
+		return true
+	}
+	version := fetchServerVersion(r)
+	if len(version) == 0 {
+		return false
+	}
+	if version != r.Config.CacheVersion() {
+		config.Debug("API cache version ", r.Config.CacheVersion(), " does not match server version ", version)
+		return true
+	}
+	return false
+}
+
+// validateArgs checks the provided args against the API metadata and returns the problems found
+func validateArgs(api *config.API, args []string) []string {
+	var problems []string
+	if len(api.Args) == 0 {
+		return problems
+	}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := parts[0], parts[1]
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") && len(value) > 1 {
+			value = value[1 : len(value)-1]
+		}
+
+		if strings.Contains(name, "[") {
+			match := mapArgRegex.FindStringSubmatch(name)
+			if match == nil {
+				problems = append(problems, fmt.Sprintf("invalid map syntax for %s, expected <name>[<index>].<key>=<value>", name))
+				continue
+			}
+			if mapArg := findArg(api, match[1]); mapArg == nil {
+				problems = append(problems, unknownArgProblem(api, match[1]))
+			} else if mapArg.Type != "map" {
+				problems = append(problems, fmt.Sprintf("%s is not a map parameter", match[1]))
+			}
+			continue
+		}
+
+		apiArg := findArg(api, name)
+		if apiArg == nil {
+			problems = append(problems, unknownArgProblem(api, name))
+			continue
+		}
+		if apiArg.Type == config.FAKE || strings.HasPrefix(value, "@") {
+			continue
+		}
+		if problem := validateValue(apiArg, name, value); len(problem) > 0 {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+func unknownArgProblem(api *config.API, name string) string {
+	problem := fmt.Sprintf("unknown parameter %s for %s", name, api.Name)
+	if suggestion := suggestArg(api, name); len(suggestion) > 0 {
+		problem += fmt.Sprintf(", did you mean %s?", suggestion)
+	}
+	return problem
+}
@@ -0,0 +1,56 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+func TestValidateArgs(t *testing.T) {
+	api := &config.API{
+		Name: "listVirtualMachines",
+		Args: []*config.APIArg{
+			{Name: "id=", Type: "uuid"},
+			{Name: "projectid=", Type: "uuid"},
+			{Name: "pagesize=", Type: "integer"},
+			{Name: "listall=", Type: "boolean"},
+			{Name: "tags=", Type: "map"},
+			{Name: "filter=", Type: config.FAKE},
+		},
+	}
+	tests := []struct {
+		args     []string
+		problems int
+	}{
+		{[]string{"id=11111111-1111-1111-1111-111111111111", "pagesize=10", "listall=true", "filter=name"}, 0},
+		{[]string{"projectid=-1"}, 0},
+		{[]string{"id=-1"}, 1},
+		{[]string{"projectid=abc"}, 1},
+		{[]string{"pagesize=ten", "listall=yes"}, 2},
+		{[]string{"tags[0].key=env", "tags[0].value=prod"}, 0},
+		{[]string{"tags=env"}, 1},
+		{[]string{"pagesze=10"}, 1},
+	}
+	for _, test := range tests {
+		if problems := validateArgs(api, test.args); len(problems) != test.problems {
+			t.Errorf("validateArgs(%v) = %v, expected %d problem(s)", test.args, problems, test.problems)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/cloudstack-cloudmonkey/config"
+)
+
+const defaultWatchInterval = 2 * time.Second
+
+// execCommand runs a command or API with the config of a request
+func execCommand(r *Request, args []string) error {
+	if command := FindCommand(args[0]); command != nil {
+		return command.Handle(NewRequest(command, r.Config, args[1:]))
+	}
+	handler := GetAPIHandler()
+	return handler.Handle(NewRequest(handler, r.Config, args))
+}
+
+// parseWatchArgs returns the interval given as -n <seconds> and the command to watch
+func parseWatchArgs(args []string) (time.Duration, []string, error) {
+	interval := defaultWatchInterval
+	if len(args) > 0 && strings.HasPrefix(args[0], "-n") {
+		value := strings.TrimPrefix(args[0], "-n")
+		args = args[1:]
+		if len(value) == 0 && len(args) > 0 {
+			value, args = args[0], args[1:]
+		}
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			interval = time.Duration(seconds * float64(time.Second))
+		} else if duration, err := time.ParseDuration(value); err == nil {
+			interval = duration
+		} else {
+			return 0, nil, fmt.Errorf("invalid interval %s, expected seconds such as -n 5", value)
+		}
+	}
+	if interval < 100*time.Millisecond {
+		return 0, nil, errors.New("the watch interval should be at least 0.1 seconds")
+	}
+	if len(args) == 0 {
+		return 0, nil, errors.New("please provide a command to watch")
+	}
+	if args[0] == "watch" {
+		return 0, nil, errors.New("watch cannot watch itself")
+	}
+	return interval, args, nil
+}
+
+// highlightLine marks a changed line, keeping the highlight across the colours within the line
+func highlightLine(theme *colorTheme, line string) string {
+	if len(theme.Changed) == 0 || len(line) == 0 {
+		return line
+	}
+	return paint(theme.Changed, strings.ReplaceAll(line, "\033[0m", "\033[0m\033["+theme.Changed+"m"))
+}
+
+// writeWatchFrame writes the output of a run, highlighting the lines that were not
+// in the output of the previous run
+func writeWatchFrame(screen io.Writer, header string, output string, previous map[string]bool, theme *colorTheme, redraw bool) {
+	if redraw {
+		fmt.Fprint(screen, "\033[H\033[2J")
+	}
+	fmt.Fprintln(screen, header)
+	fmt.Fprintln(screen)
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		if previous != nil && !previous[line] {
+			line = highlightLine(theme, line)
+		}
+		fmt.Fprintln(screen, line)
+	}
+}
+
+func init() {
+	AddCommand(&Command{
+		Name: "watch",
+		Help: "Re-runs a command or API on an interval",
+		Handle: func(r *Request) error {
+			if len(r.Args) > 0 && r.Args[len(r.Args)-1] == "-h" {
+				fmt.Println("Usage: watch [-n <seconds>] <command or API>, for example: watch -n 5 list virtualmachines filter=name,state. Press Ctrl+C to stop.")
+				return nil
+			}
+			interval, args, err := parseWatchArgs(r.Args)
+			if err != nil {
+				return err
+			}
+			config.Debug("Watching ", args, " every ", interval)
+
+			screen := outputWriter
+			redraw := isTerminal(screen)
+			if redraw {
+				screen = os.Stdout
+			}
+			// the context is set up once, every setup registers another interrupt handler
+			config.SetupContext(r.Config)
+			var previous map[string]bool
+			for {
+				var frame io.Writer = &bytes.Buffer{}
+				if redraw {
+					frame = &terminalBuffer{}
+				}
+				restore := SetOutputWriter(frame)
+				theme := colorThemeFor(r.Config)
+				err := execCommand(r, args)
+				SetOutputWriter(restore)
+				if (*r.Config.Context).Err() != nil {
+					return nil
+				}
+
+				output := fmt.Sprint(frame)
+				if err != nil {
+					output += FormatError(r.Config, err) + "\n"
+				}
+				header := fmt.Sprintf("Every %s: %s    %s", interval, strings.Join(args, " "), time.Now().Format("2006-01-02 15:04:05"))
+				writeWatchFrame(screen, header, output, previous, theme, redraw)
+				previous = make(map[string]bool)
+				for _, line := range strings.Split(output, "\n") {
+					previous[line] = true
+				}
+
+				select {
+				case <-r.Config.C:
+					return nil
+				case <-(*r.Config.Context).Done():
+					return nil
+				case <-time.After(interval):
+				}
+			}
+		},
+	})
+}
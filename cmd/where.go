@@ -0,0 +1,357 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// whereOperators are the comparison operators of where= predicates, longest first
+var whereOperators = []string{"==", "!=", ">=", "<=", "=~", "!~", ">", "<"}
+
+type wherePredicate func(row map[string]interface{}) bool
+
+type whereToken struct {
+	kind  string
+	value string
+}
+
+func whereOperatorAt(expr string, pos int) string {
+	for _, op := range whereOperators {
+		if strings.HasPrefix(expr[pos:], op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func readQuoted(expr string, pos int) (string, int, error) {
+	quote := expr[pos]
+	end := strings.IndexByte(expr[pos+1:], quote)
+	if end < 0 {
+		return "", pos, fmt.Errorf("unterminated quote at position %d", pos)
+	}
+	return expr[pos+1 : pos+1+end], pos + end + 2, nil
+}
+
+// isWhereKeyword returns true for the words that combine where= predicates
+func isWhereKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "&&", "or", "||":
+		return true
+	}
+	return false
+}
+
+// lexWhere splits a where= expression into tokens, a value after an operator
+// runs until the next space so that regular expressions need no quoting
+func lexWhere(expr string) ([]whereToken, error) {
+	var tokens []whereToken
+	depth := 0
+	pos := 0
+	for pos < len(expr) {
+		c := expr[pos]
+		switch {
+		case c == ' ' || c == '\t':
+			pos++
+		case c == '(':
+			tokens = append(tokens, whereToken{kind: "("})
+			depth++
+			pos++
+		case c == ')':
+			tokens = append(tokens, whereToken{kind: ")"})
+			depth--
+			pos++
+		case c == '"' || c == '\'':
+			value, next, err := readQuoted(expr, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, whereToken{kind: "word", value: value})
+			pos = next
+		case len(whereOperatorAt(expr, pos)) > 0:
+			op := whereOperatorAt(expr, pos)
+			tokens = append(tokens, whereToken{kind: "op", value: op})
+			pos += len(op)
+			for pos < len(expr) && expr[pos] == ' ' {
+				pos++
+			}
+			if pos < len(expr) && (expr[pos] == '"' || expr[pos] == '\'') {
+				value, next, err := readQuoted(expr, pos)
+				if err != nil {
+					return nil, err
+				}
+				tokens = append(tokens, whereToken{kind: "word", value: value})
+				pos = next
+				continue
+			}
+			start := pos
+			for pos < len(expr) && expr[pos] != ' ' && expr[pos] != '\t' {
+				pos++
+			}
+			value := expr[start:pos]
+			closing := 0
+			for depth > 0 && strings.HasSuffix(value, ")") {
+				value = value[:len(value)-1]
+				closing++
+				depth--
+			}
+			if len(value) == 0 || isWhereKeyword(value) {
+				return nil, fmt.Errorf("missing value after %s in where expression %s, quote empty values and values such as 'and'", op, expr)
+			}
+			tokens = append(tokens, whereToken{kind: "word", value: value})
+			for ; closing > 0; closing-- {
+				tokens = append(tokens, whereToken{kind: ")"})
+			}
+		default:
+			start := pos
+			for pos < len(expr) && !strings.ContainsRune(" \t()\"'", rune(expr[pos])) && len(whereOperatorAt(expr, pos)) == 0 {
+				pos++
+			}
+			word := expr[start:pos]
+			switch strings.ToLower(word) {
+			case "and", "&&":
+				tokens = append(tokens, whereToken{kind: "and"})
+			case "or", "||":
+				tokens = append(tokens, whereToken{kind: "or"})
+			default:
+				tokens = append(tokens, whereToken{kind: "word", value: word})
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// whereValues returns the values of a field path into a list of key/value pairs,
+// such as tags.env for the value of the tag with the key env
+func whereValues(value interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		if items, ok := value.([]interface{}); ok {
+			return items
+		}
+		if value == nil {
+			return nil
+		}
+		return []interface{}{value}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return whereValues(v[path[0]], path[1:])
+	case []interface{}:
+		var values []interface{}
+		for _, item := range v {
+			if pair, ok := item.(map[string]interface{}); ok {
+				if key, ok := pair["key"].(string); ok && key == path[0] {
+					values = append(values, whereValues(pair["value"], path[1:])...)
+					continue
+				}
+			}
+			values = append(values, whereValues(item, path)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// whereFieldValues returns the values of a field path in a row, resolved like the
+// paths of filter= and sortby=, or else as a path into key/value pairs such as tags.env
+func whereFieldValues(row map[string]interface{}, field string, path queryNode) []interface{} {
+	switch value := path(row).(type) {
+	case nil:
+	case []interface{}:
+		if len(value) > 0 {
+			return value
+		}
+	default:
+		return []interface{}{value}
+	}
+	if strings.Contains(field, ".") && !strings.ContainsAny(field, "[]") {
+		return whereValues(row, strings.Split(field, "."))
+	}
+	return nil
+}
+
+func whereMatches(op string, actual interface{}, expected string, pattern *regexp.Regexp) bool {
+	switch op {
+	case "==":
+		actualNumber, okActual := toNumber(actual)
+		expectedNumber, okExpected := toNumber(expected)
+		if okActual && okExpected {
+			return actualNumber == expectedNumber
+		}
+		return strings.EqualFold(jsonify(actual, ""), expected)
+	case "=~":
+		return pattern.MatchString(jsonify(actual, ""))
+	case ">":
+		return compareSortValues(actual, expected) > 0
+	case ">=":
+		return compareSortValues(actual, expected) >= 0
+	case "<":
+		return compareSortValues(actual, expected) < 0
+	case "<=":
+		return compareSortValues(actual, expected) <= 0
+	}
+	return false
+}
+
+func whereCondition(field string, op string, expected string) (wherePredicate, error) {
+	negate := false
+	switch op {
+	case "!=":
+		op, negate = "==", true
+	case "!~":
+		op, negate = "=~", true
+	}
+	var pattern *regexp.Regexp
+	if op == "=~" {
+		var err error
+		if pattern, err = regexp.Compile(expected); err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %v", expected, err)
+		}
+	}
+	path, err := compileQuery(field)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where field %s: %v", field, err)
+	}
+	return func(row map[string]interface{}) bool {
+		for _, actual := range whereFieldValues(row, field, path) {
+			if whereMatches(op, actual, expected, pattern) {
+				return !negate
+			}
+		}
+		return negate
+	}, nil
+}
+
+// compileWhere parses a where= expression such as state==Running and memory>=4096,
+// and binds tighter than or and parentheses group predicates
+func compileWhere(expr string) (wherePredicate, error) {
+	tokens, err := lexWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	peek := func() string {
+		if pos < len(tokens) {
+			return tokens[pos].kind
+		}
+		return ""
+	}
+
+	var parseOr func() (wherePredicate, error)
+	parsePrimary := func() (wherePredicate, error) {
+		if peek() == "(" {
+			pos++
+			predicate, err := parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if peek() != ")" {
+				return nil, fmt.Errorf("missing ) in where expression %s", expr)
+			}
+			pos++
+			return predicate, nil
+		}
+		if pos+3 > len(tokens) {
+			return nil, fmt.Errorf("incomplete where expression %s, expected <field><operator><value>", expr)
+		}
+		field, op, value := tokens[pos], tokens[pos+1], tokens[pos+2]
+		if field.kind != "word" || op.kind != "op" || value.kind != "word" {
+			return nil, fmt.Errorf("invalid where expression %s, expected <field><operator><value>", expr)
+		}
+		pos += 3
+		return whereCondition(field.value, op.value, value.value)
+	}
+	parseAnd := func() (wherePredicate, error) {
+		left, err := parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		for peek() == "and" {
+			pos++
+			right, err := parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			first := left
+			left = func(row map[string]interface{}) bool {
+				return first(row) && right(row)
+			}
+		}
+		return left, nil
+	}
+	parseOr = func() (wherePredicate, error) {
+		left, err := parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		for peek() == "or" {
+			pos++
+			right, err := parseAnd()
+			if err != nil {
+				return nil, err
+			}
+			first := left
+			left = func(row map[string]interface{}) bool {
+				return first(row) || right(row)
+			}
+		}
+		return left, nil
+	}
+
+	predicate, err := parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if pos < len(tokens) {
+		return nil, fmt.Errorf("unexpected %s in where expression %s", tokens[pos].kind, expr)
+	}
+	return predicate, nil
+}
+
+// whereResponse keeps the list items matching the where= predicate and updates the count
+func whereResponse(response map[string]interface{}, where wherePredicate) map[string]interface{} {
+	if where == nil {
+		return response
+	}
+	filteredResponse := make(map[string]interface{})
+	count, hasList := 0, false
+	for key, value := range response {
+		items, ok := value.([]interface{})
+		if !ok {
+			filteredResponse[key] = value
+			continue
+		}
+		hasList = true
+		rows := []interface{}{}
+		for _, item := range items {
+			if row, ok := item.(map[string]interface{}); ok && !where(row) {
+				continue
+			}
+			rows = append(rows, item)
+		}
+		count += len(rows)
+		filteredResponse[key] = rows
+	}
+	if _, ok := filteredResponse["count"]; ok && hasList {
+		filteredResponse["count"] = float64(count)
+	}
+	return filteredResponse
+}
@@ -0,0 +1,157 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLexWhere(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []whereToken
+	}{
+		{"state==Running", []whereToken{{"word", "state"}, {"op", "=="}, {"word", "Running"}}},
+		{"memory >= 4096 and name=~^web-", []whereToken{
+			{"word", "memory"}, {"op", ">="}, {"word", "4096"}, {"and", ""},
+			{"word", "name"}, {"op", "=~"}, {"word", "^web-"},
+		}},
+		{"(state!=Stopped || tags.env==prod)", []whereToken{
+			{"(", ""}, {"word", "state"}, {"op", "!="}, {"word", "Stopped"}, {"or", ""},
+			{"word", "tags.env"}, {"op", "=="}, {"word", "prod"}, {")", ""},
+		}},
+		{"name=='web 1' or displayname==\"\"", []whereToken{
+			{"word", "name"}, {"op", "=="}, {"word", "web 1"}, {"or", ""},
+			{"word", "displayname"}, {"op", "=="}, {"word", ""},
+		}},
+		{"nic[0].ipaddress!~^10\\.", []whereToken{{"word", "nic[0].ipaddress"}, {"op", "!~"}, {"word", "^10\\."}}},
+	}
+	for _, test := range tests {
+		tokens, err := lexWhere(test.expr)
+		if err != nil {
+			t.Errorf("lexWhere(%q) failed: %v", test.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(tokens, test.expected) {
+			t.Errorf("lexWhere(%q) = %v, expected %v", test.expr, tokens, test.expected)
+		}
+	}
+}
+
+func TestCompileWhere(t *testing.T) {
+	rows := []map[string]interface{}{
+		{
+			"name": "web-1", "state": "Running", "memory": float64(2048),
+			"nic":  []interface{}{map[string]interface{}{"ipaddress": "10.0.0.1"}, map[string]interface{}{"ipaddress": "1.1.1.1"}},
+			"tags": []interface{}{map[string]interface{}{"key": "env", "value": "prod"}},
+		},
+		{
+			"name": "db-1", "state": "Stopped", "memory": float64(8192),
+			"nic":  []interface{}{map[string]interface{}{"ipaddress": "1.1.1.1"}},
+			"tags": []interface{}{map[string]interface{}{"key": "env", "value": "test"}},
+		},
+		{
+			"name": "web-10", "state": "Running", "memory": float64(4096),
+			"nic": []interface{}{map[string]interface{}{"ipaddress": "10.0.0.3"}},
+		},
+	}
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{"state==Running", []string{"web-1", "web-10"}},
+		{"state==running", []string{"web-1", "web-10"}},
+		{"state!=Running", []string{"db-1"}},
+		{"memory>=4096", []string{"db-1", "web-10"}},
+		{"memory<4096", []string{"web-1"}},
+		{"memory==4096.0", []string{"web-10"}},
+		{"name=~^web-", []string{"web-1", "web-10"}},
+		{"name!~^web-", []string{"db-1"}},
+		{"tags.env==prod", []string{"web-1"}},
+		{"tags.env!=prod", []string{"db-1", "web-10"}},
+		{"nic.ipaddress==10.0.0.3", []string{"web-10"}},
+		{"nic[0].ipaddress==1.1.1.1", []string{"db-1"}},
+		{"nic[-1].ipaddress==1.1.1.1", []string{"web-1", "db-1"}},
+		{"state==Running and memory>=4096", []string{"web-10"}},
+		{"state==Stopped or memory<4096", []string{"web-1", "db-1"}},
+		{"name==db-1 or state==Running and memory>4096", []string{"db-1"}},
+		{"(name==db-1 or state==Running) and memory>=4096", []string{"db-1", "web-10"}},
+		{"name=='web-1' && (memory==2048)", []string{"web-1"}},
+		{"displayname==\"\"", nil},
+	}
+	for _, test := range tests {
+		predicate, err := compileWhere(test.expr)
+		if err != nil {
+			t.Errorf("compileWhere(%q) failed: %v", test.expr, err)
+			continue
+		}
+		var matched []string
+		for _, row := range rows {
+			if predicate(row) {
+				matched = append(matched, row["name"].(string))
+			}
+		}
+		if !reflect.DeepEqual(matched, test.expected) {
+			t.Errorf("compileWhere(%q) matched %v, expected %v", test.expr, matched, test.expected)
+		}
+	}
+}
+
+func TestCompileWhereErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"state==", "missing value after =="},
+		{"state== and memory>1", "missing value after =="},
+		{"state", "incomplete where expression"},
+		{"state==Running and", "incomplete where expression"},
+		{"state Running memory", "invalid where expression"},
+		{"(state==Running", "missing )"},
+		{"state==Running )", "unexpected )"},
+		{"name=~[", "invalid regular expression"},
+		{"nic[0==1", "invalid where field"},
+		{"name=='web", "unterminated quote"},
+	}
+	for _, test := range tests {
+		_, err := compileWhere(test.expr)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("compileWhere(%q) returned error %v, expected %q", test.expr, err, test.err)
+		}
+	}
+}
+
+func TestWhereResponse(t *testing.T) {
+	response := map[string]interface{}{
+		"count": float64(2),
+		"virtualmachine": []interface{}{
+			map[string]interface{}{"name": "web-1", "state": "Running"},
+			map[string]interface{}{"name": "db-1", "state": "Stopped"},
+		},
+	}
+	predicate, err := compileWhere("state==Running")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := whereResponse(response, predicate)
+	if result["count"] != float64(1) || len(result["virtualmachine"].([]interface{})) != 1 {
+		t.Errorf("whereResponse returned %v, expected a single item", result)
+	}
+}
@@ -0,0 +1,183 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+const (
+	minColumnWidth = 8
+	ellipsis       = "…"
+)
+
+// terminalWidth returns the width of the terminal, or 0 when the output is piped or redirected
+func terminalWidth() int {
+	if !isTerminal(outputWriter) {
+		return 0
+	}
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 0
+}
+
+// columnLayout is the width and wrapping of the columns of a table fitted to the terminal
+type columnLayout struct {
+	header []string
+	widths []int
+	wrap   []bool
+}
+
+// layoutColumns fits the columns in the output width, the widest columns are narrowed
+// first and the last columns are dropped when the others cannot be narrowed enough,
+// the overhead is the width taken by the borders and padding of a number of columns
+func (p *printer) layoutColumns(header []string, rows [][]string, overhead func(columns int) int) columnLayout {
+	layout := columnLayout{header: header}
+	if p.width <= 0 || len(header) == 0 {
+		return layout
+	}
+
+	natural := make([]int, len(header))
+	minimum := make([]int, len(header))
+	for idx, title := range header {
+		natural[idx] = runewidth.StringWidth(title)
+		for _, row := range rows {
+			if idx < len(row) && runewidth.StringWidth(row[idx]) > natural[idx] {
+				natural[idx] = runewidth.StringWidth(row[idx])
+			}
+		}
+		minimum[idx] = runewidth.StringWidth(title)
+		if minimum[idx] < minColumnWidth {
+			minimum[idx] = minColumnWidth
+		}
+		if minimum[idx] > natural[idx] {
+			minimum[idx] = natural[idx]
+		}
+	}
+	sum := func(widths []int) int {
+		total := 0
+		for _, width := range widths {
+			total += width
+		}
+		return total
+	}
+
+	columns := len(header)
+	for columns > 1 && sum(minimum[:columns])+overhead(columns) > p.width {
+		columns--
+	}
+	widths := append([]int{}, natural[:columns]...)
+	for sum(widths)+overhead(columns) > p.width {
+		widest := -1
+		for idx := range widths {
+			if widths[idx] > minimum[idx] && (widest < 0 || widths[idx] > widths[widest]) {
+				widest = idx
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+	}
+	if columns == 1 && widths[0]+overhead(1) > p.width && p.width-overhead(1) > 0 {
+		widths[0] = p.width - overhead(1)
+	}
+
+	layout.header = header[:columns]
+	layout.widths = widths
+	for _, column := range layout.header {
+		layout.wrap = append(layout.wrap, indexOf(p.wrap, column) >= 0)
+	}
+	return layout
+}
+
+// dropped returns the columns of the header that did not fit
+func (layout columnLayout) dropped(header []string) []string {
+	return header[len(layout.header):]
+}
+
+// lines returns the lines of a cell, truncated or wrapped to the width of its column
+func (layout columnLayout) lines(column int, text string) []string {
+	if column >= len(layout.widths) || runewidth.StringWidth(text) <= layout.widths[column] {
+		return []string{text}
+	}
+	width := layout.widths[column]
+	if !layout.wrap[column] {
+		return []string{runewidth.Truncate(text, width, ellipsis)}
+	}
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if len(line) > 0 && runewidth.StringWidth(line+" "+word) <= width {
+			line += " " + word
+			continue
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		for runewidth.StringWidth(word) > width {
+			part := runewidth.Truncate(word, width, "")
+			if len(part) == 0 {
+				break
+			}
+			lines = append(lines, part)
+			word = word[len(part):]
+		}
+		line = word
+	}
+	return append(lines, line)
+}
+
+// title returns the header of a column, truncated to the width of the column
+func (layout columnLayout) title(column int, text string) string {
+	if column >= len(layout.widths) {
+		return text
+	}
+	return runewidth.Truncate(text, layout.widths[column], ellipsis)
+}
+
+// cells returns the coloured lines of every cell of a row
+func (p *printer) cells(layout columnLayout, row []string) [][]string {
+	var cells [][]string
+	for idx, field := range layout.header {
+		var lines []string
+		for _, line := range layout.lines(idx, row[idx]) {
+			lines = append(lines, p.colorFieldAs(field, row[idx], line))
+		}
+		cells = append(cells, lines)
+	}
+	return cells
+}
+
+// printDroppedColumns notes the columns that were left out to fit the terminal width
+func printDroppedColumns(columns []string) {
+	if len(columns) == 0 {
+		return
+	}
+	fmt.Fprintf(outputWriter, "%d more column(s) not shown to fit the terminal: %s, use truncate=false to show all\n", len(columns), strings.Join(columns, ", "))
+}
@@ -0,0 +1,119 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestLayoutColumns(t *testing.T) {
+	header := []string{"id", "name", "description"}
+	rows := [][]string{
+		{"11111111-1111", "web-1", "a web server in the first zone"},
+		{"22222222-2222", "db-1", "database"},
+	}
+	overhead := func(columns int) int {
+		return 3 * (columns - 1)
+	}
+	tests := []struct {
+		width  int
+		wrap   []string
+		header []string
+		widths []int
+		lines  []string
+	}{
+		{0, nil, header, nil, []string{"a web server in the first zone"}},
+		{120, nil, header, []int{13, 5, 30}, []string{"a web server in the first zone"}},
+		{40, nil, header, []int{13, 5, 16}, []string{"a web server in…"}},
+		{40, []string{"description"}, header, []int{13, 5, 16}, []string{"a web server in", "the first zone"}},
+		{24, nil, []string{"id", "name"}, []int{13, 5}, nil},
+		{14, nil, []string{"id"}, []int{13}, nil},
+		{6, nil, []string{"id"}, []int{6}, nil},
+	}
+	for _, test := range tests {
+		p := &printer{width: test.width, wrap: test.wrap}
+		layout := p.layoutColumns(header, rows, overhead)
+		if !reflect.DeepEqual(layout.header, test.header) || !reflect.DeepEqual(layout.widths, test.widths) {
+			t.Errorf("layoutColumns at width %d = %v %v, expected %v %v", test.width, layout.header, layout.widths, test.header, test.widths)
+			continue
+		}
+		if dropped := layout.dropped(header); !reflect.DeepEqual(dropped, header[len(test.header):]) {
+			t.Errorf("layoutColumns at width %d dropped %v", test.width, dropped)
+		}
+		if len(test.lines) > 0 {
+			if lines := layout.lines(2, rows[0][2]); !reflect.DeepEqual(lines, test.lines) {
+				t.Errorf("layoutColumns at width %d wrapped the description as %q, expected %q", test.width, lines, test.lines)
+			}
+		}
+	}
+}
+
+func TestColumnLayoutLines(t *testing.T) {
+	tests := []struct {
+		text     string
+		width    int
+		wrap     bool
+		expected []string
+	}{
+		{"short", 10, false, []string{"short"}},
+		{"exactly 10", 10, false, []string{"exactly 10"}},
+		{"longer than ten", 10, false, []string{"longer th…"}},
+		{"longer than ten", 10, true, []string{"longer", "than ten"}},
+		{"averyveryverylongword x", 8, true, []string{"averyver", "yverylon", "gword x"}},
+		{"日本語のテキスト", 8, false, []string{"日本語…"}},
+		{"日本語のテキスト", 8, true, []string{"日本語の", "テキスト"}},
+	}
+	for _, test := range tests {
+		layout := columnLayout{header: []string{"value"}, widths: []int{test.width}, wrap: []bool{test.wrap}}
+		lines := layout.lines(0, test.text)
+		if !reflect.DeepEqual(lines, test.expected) {
+			t.Errorf("lines(%q, %d, %v) = %q, expected %q", test.text, test.width, test.wrap, lines, test.expected)
+		}
+		for _, line := range lines {
+			if runewidth.StringWidth(line) > test.width {
+				t.Errorf("lines(%q, %d, %v) returned %q wider than the column", test.text, test.width, test.wrap, line)
+			}
+		}
+	}
+}
+
+func TestPrintTableWidth(t *testing.T) {
+	response := map[string]interface{}{
+		"zone": []interface{}{
+			map[string]interface{}{"id": "cccccccc-cccc-cccc-cccc-cccccccccccc", "name": "zone1", "description": "the first zone"},
+		},
+	}
+	var out bytes.Buffer
+	previous := SetOutputWriter(&out)
+	(&printer{fieldOrder: defaultPrinter.fieldOrder, theme: noColors, width: 30}).printTable(response, nil)
+	SetOutputWriter(previous)
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	for _, line := range lines[1 : len(lines)-1] {
+		if runewidth.StringWidth(line) > 30 {
+			t.Errorf("printTable printed %q wider than 30 columns", line)
+		}
+	}
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "1 more column(s) not shown to fit the terminal: description") {
+		t.Errorf("printTable did not note the dropped column, printed %q", last)
+	}
+}
@@ -50,6 +50,9 @@ func main() {
 	acsUrl := flag.String("u", config.DEFAULT_ACS_API_ENDPOINT, "cloudStack's API endpoint URL")
 	apiKey := flag.String("k", "", "cloudStack user's API Key")
 	secretKey := flag.String("s", "", "cloudStack user's secret Key")
+	assumeYes := flag.Bool("yes", false, "assume yes for confirmation prompts")
+	query := flag.String("q", "", "JMESPath-like query expression to apply on the API response")
+	outputFile := flag.String("output-file", "", "write the formatted output to a file")
 	flag.Parse()
 	args := flag.Args()
 
@@ -87,13 +90,28 @@ func main() {
 	if *profile != "" {
 		cfg.LoadProfile(*profile)
 	}
+	cfg.AssumeYes = *assumeYes
 	config.LoadCache(cfg)
 	cli.SetConfig(cfg)
 
 	config.Debug("cmdline args:", strings.Join(os.Args, ", "))
+	if len(args) > 0 && *query != "" {
+		args = append(args, "query="+*query)
+	}
 	if len(args) > 0 {
-		if err := cli.ExecCmd(args); err != nil {
-			fmt.Println("🙈 Error:", err)
+		restoreOutput := func() error { return nil }
+		if *outputFile != "" {
+			restore, err := cmd.RedirectOutput(*outputFile, false)
+			if err != nil {
+				fmt.Println(cmd.FormatError(cfg, err))
+				os.Exit(1)
+			}
+			restoreOutput = restore
+		}
+		err := cli.ExecCmd(args)
+		restoreOutput()
+		if err != nil {
+			fmt.Println(cmd.FormatError(cfg, err))
 			os.Exit(1)
 		}
 		os.Exit(0)
@@ -42,18 +42,31 @@ type APIArg struct {
 
 // API describes a CloudStack API
 type API struct {
-	Name         string
-	Verb         string
-	Noun         string
-	Args         []*APIArg
-	RequiredArgs []string
-	Related      []string
-	Async        bool
-	Description  string
-	ResponseKeys []string
+	Name          string
+	Verb          string
+	Noun          string
+	Args          []*APIArg
+	RequiredArgs  []string
+	Related       []string
+	Async         bool
+	Description   string
+	ResponseKeys  []string
+	ResponseTypes map[string]string
+}
+
+// HasArg returns true if the API accepts an arg with the provided name
+func (api *API) HasArg(name string) bool {
+	for _, arg := range api.Args {
+		if arg.Name == name+"=" {
+			return true
+		}
+	}
+	return false
 }
 
 var apiCache map[string]*API
+var inBuiltCache bool
+var cacheVersion string
 var apiVerbMap map[string][]*API
 
 // GetAPIVerbMap returns API cache by verb
@@ -69,6 +82,18 @@ func (c *Config) GetAPIVerbMap() map[string][]*API {
 	return apiSplitMap
 }
 
+// IsInBuiltCache returns true if the in-built API cache is used, which may be
+// older than the APIs of the management server
+func (c *Config) IsInBuiltCache() bool {
+	return inBuiltCache
+}
+
+// CacheVersion returns the CloudStack version of the server the API cache was
+// discovered from, empty if unknown
+func (c *Config) CacheVersion() string {
+	return cacheVersion
+}
+
 // GetCache returns API cache by full API name
 func (c *Config) GetCache() map[string]*API {
 	if apiCache == nil {
@@ -87,6 +112,7 @@ func LoadCache(c *Config) interface{} {
 		fmt.Fprintf(os.Stderr, "Loaded in-built API cache. Failed to read API cache, please run 'sync'.\n")
 		cache = []byte(preCache)
 	}
+	inBuiltCache = err != nil
 	var data map[string]interface{}
 	_ = json.Unmarshal(cache, &data)
 	return c.UpdateCache(data)
@@ -98,10 +124,65 @@ func (c *Config) SaveCache(response map[string]interface{}) {
 	ioutil.WriteFile(c.CacheFile(), output, 0600)
 }
 
+// splitAPIName splits an API name into its lowercase verb and noun
+func splitAPIName(apiName string) (string, string) {
+	idx := 0
+	for _, chr := range apiName {
+		if unicode.IsLower(chr) {
+			idx++
+		} else {
+			break
+		}
+	}
+	return apiName[:idx], strings.ToLower(apiName[idx:])
+}
+
+// getResponseKeys returns the response keys including nested keys such as nic.ipaddress
+func getResponseKeys(response interface{}, prefix string) []string {
+	var responseKeys []string
+	respNodes, ok := response.([]interface{})
+	if !ok {
+		return responseKeys
+	}
+	for _, respNode := range respNodes {
+		if resp, ok := respNode.(map[string]interface{}); ok {
+			if resp == nil || resp["name"] == nil {
+				continue
+			}
+			name := fmt.Sprintf("%s%v", prefix, resp["name"])
+			responseKeys = append(responseKeys, name+",")
+			responseKeys = append(responseKeys, getResponseKeys(resp["response"], name+".")...)
+		}
+	}
+	return responseKeys
+}
+
+// getResponseTypes collects the types of the response keys including nested keys
+func getResponseTypes(response interface{}, prefix string, types map[string]string) map[string]string {
+	respNodes, ok := response.([]interface{})
+	if !ok {
+		return types
+	}
+	for _, respNode := range respNodes {
+		if resp, ok := respNode.(map[string]interface{}); ok {
+			if resp == nil || resp["name"] == nil {
+				continue
+			}
+			name := fmt.Sprintf("%s%v", prefix, resp["name"])
+			if respType, ok := resp["type"].(string); ok {
+				types[name] = respType
+			}
+			getResponseTypes(resp["response"], name+".", types)
+		}
+	}
+	return types
+}
+
 // UpdateCache uses auto-discovery data to update internal API cache
 func (c *Config) UpdateCache(response map[string]interface{}) interface{} {
 	apiCache = make(map[string]*API)
 	apiVerbMap = nil
+	cacheVersion, _ = response["cloudstackversion"].(string)
 
 	count := response["count"]
 	apiList := response["api"].([]interface{})
@@ -116,16 +197,7 @@ func (c *Config) UpdateCache(response map[string]interface{}) interface{} {
 		isAsync := api["isasync"].(bool)
 		description := api["description"].(string)
 
-		idx := 0
-		for _, chr := range apiName {
-			if unicode.IsLower(chr) {
-				idx++
-			} else {
-				break
-			}
-		}
-		verb := apiName[:idx]
-		noun := strings.ToLower(apiName[idx:])
+		verb, noun := splitAPIName(apiName)
 
 		var apiArgs []*APIArg
 		for _, argNode := range api["params"].([]interface{}) {
@@ -135,12 +207,17 @@ func (c *Config) UpdateCache(response map[string]interface{}) interface{} {
 				related = strings.Split(apiArg["related"].(string), ",")
 				sort.Strings(related)
 			}
+			length := 0
+			if value, ok := apiArg["length"].(float64); ok {
+				length = int(value)
+			}
 			apiArgs = append(apiArgs, &APIArg{
 				Name:        apiArg["name"].(string) + "=",
 				Type:        apiArg["type"].(string),
 				Required:    apiArg["required"].(bool),
 				Related:     related,
 				Description: apiArg["description"].(string),
+				Length:      length,
 			})
 		}
 
@@ -151,19 +228,84 @@ func (c *Config) UpdateCache(response map[string]interface{}) interface{} {
 			Description: "cloudmonkey specific response key filtering",
 		})
 
+		// Add sortby arg
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "sortby=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific sorting of list items, such as sortby=memory:desc,name",
+		})
+
+		// Add template arg
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "template=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific Go template string or @file for the template output",
+		})
+
+		// Add wide arg
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "wide=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific, set to true to show all fields in the table and column output",
+		})
+
+		// Add truncate and wrap args
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "truncate=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific, set to false to print the table and column output without fitting it to the terminal width",
+		})
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "wrap=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific columns to wrap instead of truncate when fitting the table and column output to the terminal, such as wrap=name,description",
+		})
+
+		// Add humanize arg
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "humanize=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific, set to true to show readable sizes, durations and times in the table, column and text output",
+		})
+
+		// Add where arg
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "where=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific filtering of list items, such as where=\"state==Running and memory>=4096\"",
+		})
+
+		// Add groupby and agg args
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "groupby=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific grouping of list items into summary rows, such as groupby=hostname",
+		})
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "agg=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific aggregates per group: count, sum(<field>), avg(<field>), min(<field>), max(<field>)",
+		})
+
+		// Add explode arg
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "explode=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific, prints a list item once for every element of its nested lists, such as explode=nic",
+		})
+
+		// Add query arg
+		apiArgs = append(apiArgs, &APIArg{
+			Name:        "query=",
+			Type:        FAKE,
+			Description: "cloudmonkey specific JMESPath-like response query",
+		})
+
 		sort.Slice(apiArgs, func(i, j int) bool {
 			return apiArgs[i].Name < apiArgs[j].Name
 		})
 
-		var responseKeys []string
-		for _, respNode := range api["response"].([]interface{}) {
-			if resp, ok := respNode.(map[string]interface{}); ok {
-				if resp == nil || resp["name"] == nil {
-					continue
-				}
-				responseKeys = append(responseKeys, fmt.Sprintf("%v,", resp["name"]))
-			}
-		}
+		responseKeys := getResponseKeys(api["response"], "")
 		sort.Strings(responseKeys)
 
 		var requiredArgs []string
@@ -174,14 +316,15 @@ func (c *Config) UpdateCache(response map[string]interface{}) interface{} {
 		}
 
 		apiCache[strings.ToLower(apiName)] = &API{
-			Name:         apiName,
-			Verb:         verb,
-			Noun:         noun,
-			Args:         apiArgs,
-			RequiredArgs: requiredArgs,
-			Async:        isAsync,
-			Description:  description,
-			ResponseKeys: responseKeys,
+			Name:          apiName,
+			Verb:          verb,
+			Noun:          noun,
+			Args:          apiArgs,
+			RequiredArgs:  requiredArgs,
+			Async:         isAsync,
+			Description:   description,
+			ResponseKeys:  responseKeys,
+			ResponseTypes: getResponseTypes(api["response"], "", make(map[string]string)),
 		}
 	}
 	return count
@@ -0,0 +1,89 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"strings"
+
+	ini "gopkg.in/ini.v1"
+)
+
+// ColumnsSection is the config section that overrides the default columns of
+// the table and column output per response noun, for example:
+//
+//	[columns]
+//	virtualmachine = id,name,state,hostname
+const ColumnsSection = "columns"
+
+// DefaultColumns are the built-in columns of the table and column output per response noun
+var DefaultColumns = map[string]string{
+	"account":         "id,name,accounttype,domain,state",
+	"affinitygroup":   "id,name,type,account,domain",
+	"cluster":         "id,name,hypervisortype,podname,zonename,allocationstate",
+	"diskoffering":    "id,name,disksize,iscustomized,storagetype",
+	"domain":          "id,name,path,level",
+	"host":            "id,name,type,state,resourcestate,ipaddress,hypervisor,zonename",
+	"iso":             "id,name,ostypename,isready,zonename",
+	"network":         "id,name,type,state,cidr,zonename",
+	"pod":             "id,name,gateway,netmask,zonename,allocationstate",
+	"project":         "id,name,state,domain",
+	"publicipaddress": "id,ipaddress,state,issourcenat,associatednetworkname,zonename",
+	"router":          "id,name,state,publicip,guestipaddress,hostname,zonename",
+	"securitygroup":   "id,name,description,account",
+	"serviceoffering": "id,name,cpunumber,cpuspeed,memory,storagetype",
+	"snapshot":        "id,name,state,volumename,snapshottype,created",
+	"sshkeypair":      "name,fingerprint,account",
+	"storagepool":     "id,name,type,state,scope,clustername,zonename",
+	"systemvm":        "id,name,systemvmtype,state,publicip,hostname,zonename",
+	"template":        "id,name,ostypename,isready,hypervisor,zonename",
+	"user":            "id,username,firstname,lastname,account,state",
+	"virtualmachine":  "id,name,state,zonename,nic.ipaddress",
+	"volume":          "id,name,type,state,size,virtualmachineid,zonename",
+	"vpc":             "id,name,state,cidr,zonename",
+	"zone":            "id,name,networktype,allocationstate",
+}
+
+func loadColumns(conf *ini.File) map[string]string {
+	columns := make(map[string]string)
+	for noun, value := range DefaultColumns {
+		columns[noun] = value
+	}
+	section, err := conf.GetSection(ColumnsSection)
+	if err != nil || section == nil {
+		return columns
+	}
+	for _, key := range section.Keys() {
+		columns[strings.ToLower(key.Name())] = key.Value()
+	}
+	return columns
+}
+
+// GetColumns returns the default table and column output columns of a response noun
+func (c *Config) GetColumns(noun string) []string {
+	value, ok := c.Columns[strings.ToLower(noun)]
+	if !ok {
+		return nil
+	}
+	var columns []string
+	for _, column := range strings.Split(value, ",") {
+		if column = strings.TrimSpace(column); len(column) > 0 {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
@@ -37,25 +37,67 @@ import (
 
 // Output formats
 const (
-	COLUMN  = "column"
-	CSV     = "csv"
-	JSON    = "json"
-	TABLE   = "table"
-	TEXT    = "text"
-	DEFAULT = "default"
+	COLUMN   = "column"
+	CSV      = "csv"
+	HTML     = "html"
+	JSON     = "json"
+	MARKDOWN = "markdown"
+	NDJSON   = "ndjson"
+	TABLE    = "table"
+	TEXT     = "text"
+	YAML     = "yaml"
+	TEMPLATE = "template"
+	DEFAULT  = "default"
 )
 
+// Argument validation modes
+const (
+	VALIDATE_ERROR = "error"
+	VALIDATE_WARN  = "warn"
+	VALIDATE_OFF   = "off"
+)
+
+// Timestamp formats of the humanized output
+const (
+	TIME_RELATIVE = "relative"
+	TIME_LOCAL    = "local"
+)
+
+// Colour themes of the output
+const (
+	THEME_DEFAULT = "default"
+	THEME_VIVID   = "vivid"
+	THEME_NONE    = "none"
+)
+
+// DefaultFieldOrder lists the well-known fields that are printed first
+const DefaultFieldOrder = "id,name,displayname,state,type,zonename,account,domain,created"
+
 const DEFAULT_ACS_API_ENDPOINT = "http://localhost:8080/client/api"
 
 // ServerProfile describes a management server
 type ServerProfile struct {
-	URL       string       `ini:"url"`
-	Username  string       `ini:"username"`
-	Password  string       `ini:"password"`
-	Domain    string       `ini:"domain"`
-	APIKey    string       `ini:"apikey"`
-	SecretKey string       `ini:"secretkey"`
-	Client    *http.Client `ini:"-"`
+	URL         string       `ini:"url"`
+	Username    string       `ini:"username"`
+	Password    string       `ini:"password"`
+	Domain      string       `ini:"domain"`
+	APIKey      string       `ini:"apikey"`
+	SecretKey   string       `ini:"secretkey"`
+	ProjectID   string       `ini:"projectid"`
+	ProjectName string       `ini:"projectname"`
+	DomainID    string       `ini:"domainid"`
+	DomainName  string       `ini:"domainname"`
+	Account     string       `ini:"account"`
+	Client      *http.Client `ini:"-"`
+
+	Confirm      bool   `ini:"confirm"`
+	ConfirmVerbs string `ini:"confirmverbs"`
+
+	ReadOnly      bool   `ini:"readonly"`
+	ReadOnlyAllow string `ini:"readonlyallow"`
+	ReadOnlyDeny  string `ini:"readonlydeny"`
+
+	APIDefaults map[string]string `ini:"-"`
 }
 
 // Core block describes common options for the CLI
@@ -67,6 +109,15 @@ type Core struct {
 	VerifyCert   bool   `ini:"verifycert"`
 	ProfileName  string `ini:"profile"`
 	AutoComplete bool   `ini:"autocomplete"`
+	ValidateArgs string `ini:"validateargs"`
+	NDJSONKey    bool   `ini:"ndjsonkey"`
+	FieldOrder   string `ini:"fieldorder"`
+	Wide         bool   `ini:"wide"`
+	Humanize     bool   `ini:"humanize"`
+	TimeFormat   string `ini:"timeformat"`
+	Theme        string `ini:"theme"`
+	Pager        bool   `ini:"pager"`
+	Truncate     bool   `ini:"truncate"`
 }
 
 // Config describes CLI config file and default options
@@ -76,15 +127,19 @@ type Config struct {
 	HistoryFile   string
 	LogFile       string
 	HasShell      bool
+	AssumeYes     bool
+	Columns       map[string]string
 	Core          *Core
 	ActiveProfile *ServerProfile
 	Context       *context.Context
 	Cancel        context.CancelFunc
 	C             chan bool
+	// stopInterrupt releases the interrupt handler of the previous context
+	stopInterrupt chan struct{}
 }
 
 func GetOutputFormats() []string {
-	return []string{"column", "csv", "json", "table", "text", "default"}
+	return []string{"column", "csv", "html", "json", "markdown", "ndjson", "table", "template", "text", "yaml", "default"}
 }
 
 func CheckIfValuePresent(dataset []string, element string) bool {
@@ -151,6 +206,10 @@ func defaultCoreConfig() Core {
 		VerifyCert:   true,
 		ProfileName:  "localcloud",
 		AutoComplete: true,
+		ValidateArgs: VALIDATE_ERROR,
+		FieldOrder:   DefaultFieldOrder,
+		Pager:        true,
+		Truncate:     true,
 	}
 }
 
@@ -188,16 +247,31 @@ func GetProfiles() []string {
 }
 
 func SetupContext(cfg *Config) {
-	cfg.C = make(chan bool)
+	if cfg.stopInterrupt != nil {
+		close(cfg.stopInterrupt)
+	}
+	stop := make(chan struct{})
+	cfg.stopInterrupt = stop
+	c := make(chan bool)
+	cfg.C = c
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt)
 	ctx, cancel := context.WithCancel(context.Background())
 	cfg.Context = &ctx
 	cfg.Cancel = cancel
 	go func() {
-		<-signals
-		cfg.Cancel()
-		cfg.C <- true
+		// interrupts are handled until the next context replaces this one
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			cancel()
+			select {
+			case c <- true:
+			case <-stop:
+			}
+		case <-stop:
+		}
+		<-stop
 	}()
 }
 
@@ -282,6 +356,15 @@ func saveConfig(cfg *Config) *Config {
 			core.AutoComplete = true
 			core.Output = JSON
 		}
+		if len(core.ValidateArgs) == 0 {
+			core.ValidateArgs = VALIDATE_WARN
+		}
+		if !conf.Section(ini.DEFAULT_SECTION).HasKey("pager") {
+			core.Pager = true
+		}
+		if !conf.Section(ini.DEFAULT_SECTION).HasKey("truncate") {
+			core.Truncate = true
+		}
 		cfg.Core = core
 	}
 
@@ -301,13 +384,15 @@ func saveConfig(cfg *Config) *Config {
 		conf.Section(cfg.Core.ProfileName).MapTo(profile)
 		setActiveProfile(cfg, profile)
 	}
+	cfg.ActiveProfile.APIDefaults = loadAPIDefaults(conf, cfg.Core.ProfileName)
+	cfg.Columns = loadColumns(conf)
 	// Save
 	conf.SaveTo(cfg.ConfigFile)
 
 	// Update available profiles list
 	profiles = []string{}
 	for _, profile := range conf.Sections() {
-		if profile.Name() == ini.DEFAULT_SECTION {
+		if !isProfileSection(profile.Name()) {
 			continue
 		}
 		profiles = append(profiles, profile.Name())
@@ -327,6 +412,7 @@ func (c *Config) LoadProfile(name string) {
 	}
 	profile := new(ServerProfile)
 	conf.Section(name).MapTo(profile)
+	profile.APIDefaults = loadAPIDefaults(conf, name)
 	setActiveProfile(c, profile)
 	c.Core.ProfileName = name
 }
@@ -364,6 +450,26 @@ func (c *Config) UpdateConfig(key string, value string, update bool) {
 		c.ActiveProfile.APIKey = value
 	case "secretkey":
 		c.ActiveProfile.SecretKey = value
+	case "projectid":
+		c.ActiveProfile.ProjectID = value
+	case "projectname":
+		c.ActiveProfile.ProjectName = value
+	case "domainid":
+		c.ActiveProfile.DomainID = value
+	case "domainname":
+		c.ActiveProfile.DomainName = value
+	case "account":
+		c.ActiveProfile.Account = value
+	case "confirm":
+		c.ActiveProfile.Confirm = value == "true"
+	case "confirmverbs":
+		c.ActiveProfile.ConfirmVerbs = value
+	case "readonly":
+		c.ActiveProfile.ReadOnly = value == "true"
+	case "readonlyallow":
+		c.ActiveProfile.ReadOnlyAllow = value
+	case "readonlydeny":
+		c.ActiveProfile.ReadOnlyDeny = value
 	case "verifycert":
 		c.Core.VerifyCert = value == "true"
 	case "debug":
@@ -374,6 +480,24 @@ func (c *Config) UpdateConfig(key string, value string, update bool) {
 		}
 	case "autocomplete":
 		c.Core.AutoComplete = value == "true"
+	case "validateargs":
+		c.Core.ValidateArgs = value
+	case "ndjsonkey":
+		c.Core.NDJSONKey = value == "true"
+	case "fieldorder":
+		c.Core.FieldOrder = value
+	case "wide":
+		c.Core.Wide = value == "true"
+	case "humanize":
+		c.Core.Humanize = value == "true"
+	case "timeformat":
+		c.Core.TimeFormat = value
+	case "theme":
+		c.Core.Theme = value
+	case "pager":
+		c.Core.Pager = value == "true"
+	case "truncate":
+		c.Core.Truncate = value == "true"
 	default:
 		fmt.Println("Invalid option provided:", key)
 		return
@@ -0,0 +1,113 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/shlex"
+	ini "gopkg.in/ini.v1"
+)
+
+// DefaultsSectionSuffix is the suffix of a profile's default API args section,
+// for example [localcloud.defaults] holds the defaults for the localcloud profile
+const DefaultsSectionSuffix = ".defaults"
+
+func isProfileSection(name string) bool {
+	return name != ini.DEFAULT_SECTION && name != ColumnsSection && !strings.HasSuffix(name, DefaultsSectionSuffix)
+}
+
+// loadAPIDefaults reads the default API args of a profile, keyed by API name,
+// verb or glob pattern such as list*
+func loadAPIDefaults(conf *ini.File, profileName string) map[string]string {
+	defaults := make(map[string]string)
+	section, err := conf.GetSection(profileName + DefaultsSectionSuffix)
+	if err != nil || section == nil {
+		return defaults
+	}
+	for _, key := range section.Keys() {
+		defaults[strings.ToLower(key.Name())] = key.Value()
+	}
+	return defaults
+}
+
+func defaultsPatternMatches(pattern string, api *API) bool {
+	if pattern == strings.ToLower(api.Verb) {
+		return true
+	}
+	matched, err := path.Match(pattern, strings.ToLower(api.Name))
+	return err == nil && matched
+}
+
+// GetDefaultArgs returns the default args configured for an API that it accepts,
+// the args of more specific patterns override the ones from verb and glob patterns
+func (c *Config) GetDefaultArgs(api *API) []string {
+	if c.ActiveProfile == nil || api == nil || len(c.ActiveProfile.APIDefaults) == 0 {
+		return nil
+	}
+
+	var patterns []string
+	for pattern := range c.ActiveProfile.APIDefaults {
+		if defaultsPatternMatches(pattern, api) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	specificity := func(pattern string) int {
+		if pattern == strings.ToLower(api.Name) {
+			return len(api.Name) + 1
+		}
+		if pattern == strings.ToLower(api.Verb) {
+			return 0
+		}
+		return len(strings.Trim(pattern, "*?"))
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if specificity(patterns[i]) == specificity(patterns[j]) {
+			return patterns[i] < patterns[j]
+		}
+		return specificity(patterns[i]) < specificity(patterns[j])
+	})
+
+	var keys []string
+	args := make(map[string]string)
+	for _, pattern := range patterns {
+		values, err := shlex.Split(c.ActiveProfile.APIDefaults[pattern])
+		if err != nil {
+			Debug("Failed to parse default args for ", pattern, ": ", err)
+			continue
+		}
+		for _, arg := range values {
+			key := strings.SplitN(arg, "=", 2)[0]
+			if !api.HasArg(key) {
+				continue
+			}
+			if _, ok := args[key]; !ok {
+				keys = append(keys, key)
+			}
+			args[key] = arg
+		}
+	}
+
+	var defaultArgs []string
+	for _, key := range keys {
+		defaultArgs = append(defaultArgs, args[key])
+	}
+	return defaultArgs
+}
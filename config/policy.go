@@ -0,0 +1,84 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// ReadOnlyVerbs are the API verbs allowed by read-only profiles
+var ReadOnlyVerbs = []string{"list", "get", "query", "find", "is"}
+
+// DefaultConfirmVerbs are the API verbs that need confirmation when no list is configured
+const DefaultConfirmVerbs = "delete,destroy,expunge,remove,reset"
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, strings.ToLower(item))
+		}
+	}
+	return items
+}
+
+// RequiresConfirmation returns true if the active profile needs the API call to be confirmed
+func (c *Config) RequiresConfirmation(api *API) bool {
+	if c.ActiveProfile == nil || !c.ActiveProfile.Confirm || api == nil {
+		return false
+	}
+	verbs := c.ActiveProfile.ConfirmVerbs
+	if len(strings.TrimSpace(verbs)) == 0 {
+		verbs = DefaultConfirmVerbs
+	}
+	return CheckIfValuePresent(splitList(verbs), strings.ToLower(api.Verb))
+}
+
+func matchesAnyPattern(patterns []string, apiName string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, strings.ToLower(apiName)); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReadOnly returns true if the active profile is read-only
+func (c *Config) IsReadOnly() bool {
+	return c.ActiveProfile != nil && c.ActiveProfile.ReadOnly
+}
+
+// IsAPIAllowed returns false if the active read-only profile does not allow the API,
+// the deny and allow lists of API names or glob patterns take precedence over the verb
+func (c *Config) IsAPIAllowed(apiName string) bool {
+	if !c.IsReadOnly() {
+		return true
+	}
+	if matchesAnyPattern(splitList(c.ActiveProfile.ReadOnlyDeny), apiName) {
+		return false
+	}
+	if matchesAnyPattern(splitList(c.ActiveProfile.ReadOnlyAllow), apiName) {
+		return true
+	}
+	verb, _ := splitAPIName(apiName)
+	if api := c.GetCache()[strings.ToLower(apiName)]; api != nil {
+		verb = api.Verb
+	}
+	return CheckIfValuePresent(ReadOnlyVerbs, strings.ToLower(verb))
+}
@@ -46,7 +46,39 @@ func renderPrompt(prompt string) string {
 	return prompt
 }
 
+func contextName(id string, name string) string {
+	if len(name) > 0 {
+		return name
+	}
+	return id
+}
+
+// GetContext returns the active project, domain and account context
+func (c *Config) GetContext() string {
+	if c.ActiveProfile == nil {
+		return ""
+	}
+	var context []string
+	if len(c.ActiveProfile.ProjectID) > 0 {
+		context = append(context, "project="+contextName(c.ActiveProfile.ProjectID, c.ActiveProfile.ProjectName))
+	}
+	if len(c.ActiveProfile.DomainID) > 0 {
+		context = append(context, "domain="+contextName(c.ActiveProfile.DomainID, c.ActiveProfile.DomainName))
+	}
+	if len(c.ActiveProfile.Account) > 0 {
+		context = append(context, "account="+c.ActiveProfile.Account)
+	}
+	return strings.Join(context, " ")
+}
+
 // GetPrompt returns prompt that the CLI should use
 func (c *Config) GetPrompt() string {
-	return fmt.Sprintf("(%s) %s > ", c.Core.ProfileName, renderPrompt(c.Core.Prompt))
+	profile := c.Core.ProfileName
+	if c.IsReadOnly() {
+		profile = profile + " [read-only]"
+	}
+	if context := c.GetContext(); len(context) > 0 {
+		profile = profile + " " + context
+	}
+	return fmt.Sprintf("(%s) %s > ", profile, renderPrompt(c.Core.Prompt))
 }